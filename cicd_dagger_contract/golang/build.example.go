@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"dagger/goserv/internal/dagger"
+
+	"github.com/jpbarto/cicd-local/pkg/pipelinectx"
 )
 
 // Build builds a multi-architecture Docker image and exports it as an OCI tarball
@@ -28,6 +30,12 @@ func (m *Goserv) Build(
 	// Print to show the message
 	println(output)
 
-	// Return a dummy file since the function signature requires *dagger.File
-	return dag.Container().From("alpine:latest").File("/etc/hostname"), nil
+	// Record what was built so Deliver doesn't have to re-derive it.
+	buildContext := pipelinectx.BuildContext{
+		Meta:                pipelinectx.NewMeta("", ""),
+		ImageArtifactDigest: "",
+		ReleaseCandidate:    releaseCandidate,
+	}
+
+	return pipelinectx.Write(dag.Directory(), buildContext)
 }
@@ -2,12 +2,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"time"
 
 	"dagger/goserv/internal/dagger"
 
-	"dagger.io/dagger/dag"
+	cicd "github.com/jpbarto/cicd-local/privileged"
+	"github.com/jpbarto/cicd-local/pkg/pipelinectx"
 )
 
 // Deliver publishes the goserv container and Helm chart to repositories
@@ -27,27 +26,43 @@ func (m *Goserv) Deliver(
 	// +optional
 	// Build as release candidate (appends -rc to version tag)
 	releaseCandidate bool,
-) (*dagger.File, error) {
+) (*dagger.Directory, error) {
 	// Perform delivery operations (container push, chart publish)
 	// ... delivery logic here ...
+	imageRef := containerRepository + "/goserv:1.0.0"
 
-	// Create delivery context
-	deliveryContext := map[string]interface{}{
-		"timestamp":           time.Now().Format(time.RFC3339),
-		"imageReference":      containerRepository + "/goserv:1.0.0",
-		"chartReference":      helmRepository + "/goserv:0.1.0",
-		"containerRepository": containerRepository,
-		"helmRepository":      helmRepository,
-		"releaseCandidate":    releaseCandidate,
+	// Sign the pushed image when cosign is configured so Deploy can refuse
+	// to install anything that isn't both signed and accounted for. Signing
+	// is additive, not mandatory - skip it rather than failing the build
+	// when neither COSIGN_EXPERIMENTAL nor a cosign key is set up.
+	if cicd.SigningConfigured() {
+		if _, err := cicd.SignArtifact(ctx, dag, imageRef); err != nil {
+			return nil, err
+		}
+	}
+	sbom, err := cicd.GenerateSBOM(ctx, dag, imageRef)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cicd.AttestArtifact(ctx, dag, imageRef, cicd.SBOMPredicateType, sbom); err != nil {
+		return nil, err
+	}
+
+	deliveryContext := pipelinectx.DeliveryContext{
+		Meta:                pipelinectx.NewMeta("", ""),
+		ImageReference:      imageRef,
+		ChartReference:      helmRepository + "/goserv:0.1.0",
+		ContainerRepository: containerRepository,
+		HelmRepository:      helmRepository,
+		ReleaseCandidate:    releaseCandidate,
 	}
 
-	contextJSON, err := json.MarshalIndent(deliveryContext, "", "  ")
+	// Write the context file and, when COSIGN_KEY is configured, sign it so
+	// Deploy can detect tampering in transit between stages.
+	contextDir, err := pipelinectx.WriteSigned(ctx, dag, dag.Directory(), deliveryContext)
 	if err != nil {
 		return nil, err
 	}
 
-	// Return as file
-	return dag.Directory().
-		WithNewFile("delivery-context.json", string(contextJSON)).
-		File("delivery-context.json"), nil
+	return contextDir, nil
 }
@@ -2,12 +2,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"time"
 
 	"dagger/goserv/internal/dagger"
 
-	"dagger.io/dagger/dag"
+	cicd "github.com/jpbarto/cicd-local/privileged"
+	"github.com/jpbarto/cicd-local/pkg/pipelinectx"
 )
 
 // Deploy installs the Helm chart from a Helm repository to a Kubernetes cluster
@@ -29,42 +28,65 @@ func (m *Goserv) Deploy(
 	// Container repository URL (default: ttl.sh)
 	containerRepository string,
 	// +optional
-	// Delivery context from Deliver function
-	deliveryContext *dagger.File,
+	// Delivery context directory from Deliver function
+	deliveryContext *dagger.Directory,
 	// +optional
 	// Build as release candidate (appends -rc to version tag)
 	releaseCandidate bool,
+	// +optional
+	// Cosign public key used to verify the image and delivery context before deploying (keyless image verification when empty)
+	cosignPublicKey string,
 ) (*dagger.File, error) {
-	// Extract info from delivery context if provided
-	var imageRef, chartRef string
+	// Extract info from delivery context if provided, verifying it against
+	// its signature (written by Deliver's pipelinectx.WriteSigned) whenever
+	// a public key is configured.
+	var imageRef string
 	if deliveryContext != nil {
-		contextContent, _ := deliveryContext.Contents(ctx)
-		var delContext map[string]interface{}
-		json.Unmarshal([]byte(contextContent), &delContext)
-		imageRef = delContext["imageReference"].(string)
-		chartRef = delContext["chartReference"].(string)
+		delContext, err := pipelinectx.ReadSigned[pipelinectx.DeliveryContext](ctx, dag, deliveryContext, cosignPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		imageRef = delContext.ImageReference
 	}
 
-	// Perform deployment (helm install/upgrade)
-	// ... deployment logic here ...
+	// Refuse to deploy an image that isn't signed.
+	if imageRef != "" {
+		if err := cicd.VerifySignature(ctx, dag, imageRef, cosignPublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	const releaseName = "goserv"
+	const namespace = "default"
 
-	// Create deployment context
-	deploymentContext := map[string]interface{}{
-		"timestamp":      time.Now().Format(time.RFC3339),
-		"endpoint":       "http://goserv.default.svc.cluster.local:8080",
-		"releaseName":    "goserv",
-		"namespace":      "default",
-		"chartVersion":   "0.1.0",
-		"imageReference": imageRef,
+	// Record the release's current revision (if it's been deployed before)
+	// so Validate can roll back to it if the new deployment turns out to be
+	// unhealthy.
+	previousRevision := 0
+	if kubeconfig != nil {
+		kubeconfigContent, err := kubeconfig.Contents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		kubeconfigSecret := dag.SetSecret("kubeconfig", kubeconfigContent)
+
+		if status, err := cicd.HelmStatus(ctx, dag, releaseName, namespace, kubeconfigSecret); err == nil {
+			previousRevision = status.Version
+		}
 	}
 
-	contextJSON, err := json.MarshalIndent(deploymentContext, "", "  ")
-	if err != nil {
-		return nil, err
+	// Perform deployment (helm install/upgrade)
+	// ... deployment logic here ...
+
+	deploymentContext := pipelinectx.DeploymentContext{
+		Meta:             pipelinectx.NewMeta("", ""),
+		Endpoint:         "http://goserv.default.svc.cluster.local:8080",
+		ReleaseName:      releaseName,
+		Namespace:        namespace,
+		ChartVersion:     "0.1.0",
+		ImageReference:   imageRef,
+		PreviousRevision: previousRevision,
 	}
 
-	// Return as file
-	return dag.Directory().
-		WithNewFile("deployment-context.json", string(contextJSON)).
-		File("deployment-context.json"), nil
+	return pipelinectx.Write(dag.Directory(), deploymentContext)
 }
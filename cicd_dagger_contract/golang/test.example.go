@@ -2,11 +2,10 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 
 	"dagger/goserv/internal/dagger"
 
-	"dagger.io/dagger/dag"
+	"github.com/jpbarto/cicd-local/pkg/pipelinectx"
 )
 
 // UnitTest runs the goserv container and executes unit tests against it
@@ -51,18 +50,20 @@ func (m *Goserv) IntegrationTest(
 	// Extract endpoint from deployment context if provided
 	var targetUrl string
 	if deploymentContext != nil {
-		contextContent, _ := deploymentContext.Contents(ctx)
-		var context map[string]interface{}
-		json.Unmarshal([]byte(contextContent), &context)
-		targetUrl = context["endpoint"].(string)
+		depContext, err := pipelinectx.Read[pipelinectx.DeploymentContext](ctx, deploymentContext)
+		if err != nil {
+			return "", err
+		}
+		targetUrl = depContext.Endpoint
 	}
 
 	// Check validation status if provided
 	if validationContext != nil {
-		valContent, _ := validationContext.Contents(ctx)
-		var valContext map[string]interface{}
-		json.Unmarshal([]byte(valContent), &valContext)
-		if valContext["status"].(string) != "healthy" {
+		valContext, err := pipelinectx.Read[pipelinectx.ValidationContext](ctx, validationContext)
+		if err != nil {
+			return "", err
+		}
+		if valContext.Status != "healthy" {
 			return "", nil // Skip tests if validation failed
 		}
 	}
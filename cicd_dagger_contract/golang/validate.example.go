@@ -2,12 +2,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"time"
 
 	"dagger/goserv/internal/dagger"
 
-	"dagger.io/dagger/dag"
+	cicd "github.com/jpbarto/cicd-local/privileged"
+	"github.com/jpbarto/cicd-local/pkg/pipelinectx"
 )
 
 // Validate runs the validation script to verify that the deployment is healthy and functioning correctly
@@ -21,43 +20,61 @@ func (m *Goserv) Validate(
 	// +optional
 	// Deployment context from Deploy function
 	deploymentContext *dagger.File,
+	// +optional
+	// Kubernetes config file content, needed to roll back an unhealthy release
+	kubeconfig *dagger.File,
 ) (*dagger.File, error) {
 	// Extract deployment information from context if provided
-	var depContext map[string]interface{}
+	var depContext pipelinectx.DeploymentContext
 	if deploymentContext != nil {
-		contextContent, err := deploymentContext.Contents(ctx)
+		var err error
+		depContext, err = pipelinectx.Read[pipelinectx.DeploymentContext](ctx, deploymentContext)
 		if err != nil {
 			return nil, err
 		}
-		json.Unmarshal([]byte(contextContent), &depContext)
-	}
-	if err := json.Unmarshal([]byte(contextContent), &depContext); err != nil {
-		return nil, err
 	}
 
-	endpoint := depContext["endpoint"].(string)
-	releaseName := depContext["releaseName"].(string)
+	var kubeconfigSecret *dagger.Secret
+	if kubeconfig != nil {
+		kubeconfigContent, err := kubeconfig.Contents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		kubeconfigSecret = dag.SetSecret("kubeconfig", kubeconfigContent)
+	}
 
-	// Perform validation checks
-	// ... validation logic here ...
+	// Perform validation checks: ask Helm whether the release actually
+	// landed in a healthy state rather than assuming it did.
+	status := "unknown"
+	if kubeconfigSecret != nil && depContext.ReleaseName != "" {
+		helmStatus, err := cicd.HelmStatus(ctx, dag, depContext.ReleaseName, depContext.Namespace, kubeconfigSecret)
+		if err != nil {
+			return nil, err
+		}
+		if helmStatus.Info.Status == "deployed" {
+			status = "healthy"
+		} else {
+			status = "unhealthy"
+		}
+	}
 
-	// Create validation context
-	validationContext := map[string]interface{}{
-		"timestamp":       time.Now().Format(time.RFC3339),
-		"releaseName":     releaseName,
-		"endpoint":        endpoint,
-		"status":          "healthy",
-		"healthChecks":    []string{"pod-ready", "service-available"},
-		"readinessChecks": []string{"http-200", "metrics-available"},
+	// An unhealthy deployment rolls itself back to the last known-good
+	// revision rather than leaving IntegrationTest to silently skip.
+	if status == "unhealthy" {
+		if _, err := cicd.HelmRollback(ctx, dag, depContext.ReleaseName, depContext.Namespace, depContext.PreviousRevision, kubeconfigSecret); err != nil {
+			return nil, err
+		}
+		status = "rolled-back"
 	}
 
-	contextJSON, err := json.MarshalIndent(validationContext, "", "  ")
-	if err != nil {
-		return nil, err
+	validationContext := pipelinectx.ValidationContext{
+		Meta:            pipelinectx.NewMeta("", ""),
+		ReleaseName:     depContext.ReleaseName,
+		Endpoint:        depContext.Endpoint,
+		Status:          status,
+		HealthChecks:    []string{"pod-ready", "service-available"},
+		ReadinessChecks: []string{"http-200", "metrics-available"},
 	}
 
-	// Return as file
-	return dag.Directory().
-		WithNewFile("validation-context.json", string(contextJSON)).
-		File("validation-context.json"), nil
+	return pipelinectx.Write(dag.Directory(), validationContext)
 }
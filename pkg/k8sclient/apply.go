@@ -0,0 +1,46 @@
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Apply performs a server-side apply of obj, using FieldManager as the
+// owning field manager. It is the native equivalent of `kubectl apply -f`.
+//
+// Conflicts with another field manager are surfaced as-is; callers can test
+// them with IsConflict and decide whether to retry with force.
+func (c *Client) Apply(ctx context.Context, obj *unstructured.Unstructured, force bool) (*unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	resource, err := c.resourceFor(gvk, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s/%s for apply: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	applied, err := resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server-side apply failed for %s/%s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	return applied, nil
+}
+
+// GVKFromUnstructured is a small convenience wrapper so callers decoding
+// manifests off disk don't need to import apimachinery/runtime/schema
+// themselves just to build a GroupVersionKind.
+func GVKFromUnstructured(group, version, kind string) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: group, Version: version, Kind: kind}
+}
@@ -0,0 +1,94 @@
+// Package k8sclient provides a native k8s.io/client-go based subsystem for
+// talking to a Kubernetes cluster. It replaces the previous approach of
+// shelling out to the bitnami/kubectl container image on every call, which
+// avoids a per-call image pull and gives typed error handling (e.g.
+// distinguishing NotFound/Conflict via k8s.io/apimachinery/pkg/api/errors).
+package k8sclient
+
+import (
+	"fmt"
+
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// FieldManager is the field manager name used for all server-side apply
+// operations performed through this package.
+const FieldManager = "cicd-local"
+
+// Client wraps a dynamic client, a typed clientset, and a RESTMapper built
+// from a single kubeconfig. It is the native replacement for the
+// kubectl-shell-out calls previously made from cicd.Kubectl*.
+type Client struct {
+	Dynamic   dynamic.Interface
+	Clientset kubernetes.Interface
+	Mapper    meta.RESTMapper
+}
+
+// New builds a Client from raw kubeconfig bytes (as loaded from the injected
+// secret by the privileged package). It resolves a REST config via
+// clientcmd.RESTConfigFromKubeConfig and constructs a dynamic client plus a
+// deferred-discovery RESTMapper so callers can resolve GroupVersionKinds to
+// resources without hardcoding API versions.
+func New(kubeconfig []byte) (*Client, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rest config from kubeconfig: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create typed clientset: %w", err)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	return &Client{
+		Dynamic:   dynamicClient,
+		Clientset: clientset,
+		Mapper:    mapper,
+	}, nil
+}
+
+// resourceFor resolves a GroupVersionKind to a namespaced or cluster-scoped
+// dynamic.ResourceInterface using the client's RESTMapper.
+func (c *Client) resourceFor(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s: %w", gvk.String(), err)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return c.Dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+	}
+	return c.Dynamic.Resource(mapping.Resource), nil
+}
+
+// IsNotFound reports whether err represents a Kubernetes "not found" API
+// error, unwrapping through kubeerrors.IsNotFound.
+func IsNotFound(err error) bool {
+	return kubeerrors.IsNotFound(err)
+}
+
+// IsConflict reports whether err represents a Kubernetes "conflict" API
+// error, typically returned when a server-side apply is rejected because
+// another field manager owns a conflicting field.
+func IsConflict(err error) bool {
+	return kubeerrors.IsConflict(err)
+}
@@ -0,0 +1,47 @@
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Get retrieves a single resource identified by gvk/namespace/name and
+// returns it as both an *unstructured.Unstructured and its JSON encoding, so
+// callers that only want the raw JSON (as the old `kubectl get -o json`
+// shell-out returned) don't have to re-marshal it themselves.
+func (c *Client) Get(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, string, error) {
+	resource, err := c.resourceFor(gvk, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+
+	obj, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get %s %q in namespace %q: %w", gvk.Kind, name, namespace, err)
+	}
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal %s %q: %w", gvk.Kind, name, err)
+	}
+
+	return obj, string(data), nil
+}
+
+// GetByKind resolves kind (a bare resource kind such as "Pod" or
+// "Deployment", case-insensitive) to a GroupVersionKind using the preferred
+// version reported by the cluster's discovery data, then behaves like Get.
+// This mirrors how `kubectl get <kind>/<name>` resolves a bare kind without
+// requiring the caller to know its apiVersion up front.
+func (c *Client) GetByKind(ctx context.Context, namespace, kind, name string) (*unstructured.Unstructured, string, error) {
+	mapping, err := c.Mapper.RESTMapping(schema.GroupKind{Kind: kind})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve kind %q: %w", kind, err)
+	}
+	return c.Get(ctx, mapping.GroupVersionKind, namespace, name)
+}
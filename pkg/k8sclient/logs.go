@@ -0,0 +1,56 @@
+package k8sclient
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// LogOptions configures a Logs call.
+type LogOptions struct {
+	// Container selects a single container in a multi-container pod.
+	// Leave empty when the pod has only one container.
+	Container string
+	// TailLines limits the stream to the last N lines, mirroring `--tail`.
+	TailLines *int64
+	// Follow keeps the stream open for new log lines, mirroring `-f`.
+	Follow bool
+}
+
+// Logs opens a streaming log read for podName via
+// CoreV1().Pods(namespace).GetLogs, the native equivalent of `kubectl logs`.
+// The caller is responsible for closing the returned stream.
+func (c *Client) Logs(ctx context.Context, namespace, podName string, opts LogOptions) (io.ReadCloser, error) {
+	req := c.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: opts.Container,
+		TailLines: opts.TailLines,
+		Follow:    opts.Follow,
+	})
+
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log stream for pod %q in namespace %q: %w", podName, namespace, err)
+	}
+	return stream, nil
+}
+
+// ReadAllLogLines drains a log stream returned by Logs into a string,
+// closing the stream when done.
+func ReadAllLogLines(stream io.ReadCloser) (string, error) {
+	defer stream.Close()
+
+	var out []byte
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		out = append(out, scanner.Bytes()...)
+		out = append(out, '\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read log stream: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,66 @@
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForwarder holds the channels used to control and observe a running
+// port-forward session started by PortForward.
+type PortForwarder struct {
+	// StopCh, when closed, terminates the port-forward.
+	StopCh chan struct{}
+	// ReadyCh is closed once the forwarded ports are ready to accept
+	// connections.
+	ReadyCh chan struct{}
+	// ErrCh receives the forwarder's terminal error, if any, once the
+	// session ends.
+	ErrCh chan error
+}
+
+// PortForward opens an SPDY-based port-forward session to podName, mirroring
+// `kubectl port-forward`. ports follow the kubectl "localPort:remotePort"
+// convention. The forward runs in a background goroutine; call Stop (close
+// StopCh) to tear it down.
+func (c *Client) PortForward(ctx context.Context, kubeconfig []byte, namespace, podName string, ports []string, out, errOut interface{ Write([]byte) (int, error) }) (*PortForwarder, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rest config from kubeconfig: %w", err)
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SPDY round tripper: %w", err)
+	}
+
+	hostURL := restConfig.Host
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace, podName)
+	req, err := http.NewRequest(http.MethodPost, hostURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build port-forward request: %w", err)
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL)
+
+	pf := &PortForwarder{
+		StopCh:  make(chan struct{}),
+		ReadyCh: make(chan struct{}),
+		ErrCh:   make(chan error, 1),
+	}
+
+	fw, err := portforward.New(dialer, ports, pf.StopCh, pf.ReadyCh, out, errOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize port-forward for pod %q: %w", podName, err)
+	}
+
+	go func() {
+		pf.ErrCh <- fw.ForwardPorts()
+	}()
+
+	return pf, nil
+}
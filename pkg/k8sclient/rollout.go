@@ -0,0 +1,122 @@
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RolloutStatus reports whether a Deployment/StatefulSet/DaemonSet has
+// finished rolling out: its observedGeneration has caught up to the desired
+// generation and its readyReplicas matches the desired replica count (or,
+// for resources exposing conditions, the named condition reports "True").
+type RolloutStatus struct {
+	ObservedGeneration int64
+	Generation         int64
+	ReadyReplicas      int64
+	Replicas           int64
+	Ready              bool
+}
+
+// rolloutStatusFromObject extracts rollout status fields shared by
+// Deployment, StatefulSet, and DaemonSet, all of which expose
+// .metadata.generation / .status.observedGeneration / .status.readyReplicas
+// / .status.replicas in the same shape.
+func rolloutStatusFromObject(obj *unstructured.Unstructured) (RolloutStatus, error) {
+	generation := obj.GetGeneration()
+
+	observedGeneration, _, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil {
+		return RolloutStatus{}, err
+	}
+
+	readyReplicas, _, err := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	if err != nil {
+		return RolloutStatus{}, err
+	}
+
+	replicas, _, err := unstructured.NestedInt64(obj.Object, "status", "replicas")
+	if err != nil {
+		return RolloutStatus{}, err
+	}
+
+	status := RolloutStatus{
+		ObservedGeneration: observedGeneration,
+		Generation:         generation,
+		ReadyReplicas:      readyReplicas,
+		Replicas:           replicas,
+	}
+	status.Ready = observedGeneration >= generation && readyReplicas == replicas
+
+	return status, nil
+}
+
+// conditionStatus looks up the named .status.conditions[].type entry and
+// returns its "status" field ("True"/"False"/"Unknown"), mirroring how
+// kubectl wait --for=condition=<type> resolves a condition.
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) (string, bool, error) {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cond["type"] != conditionType {
+			continue
+		}
+		status, _ := cond["status"].(string)
+		return status, true, nil
+	}
+
+	return "", false, nil
+}
+
+// PodEventsAndLogs bundles diagnostic information gathered when a rollout
+// wait times out, so callers can surface actionable failure output without a
+// second manual invocation.
+type PodEventsAndLogs struct {
+	Events   []corev1.Event
+	PodLogs  map[string]string
+	Failures []string
+}
+
+// diagnoseFailingPods collects recent events and the last tailLines of logs
+// for pods in namespace matching the given label selector, for inclusion in
+// a RolloutTimeoutError.
+func (c *Client) diagnoseFailingPods(ctx context.Context, namespace, labelSelector string, tailLines int64) PodEventsAndLogs {
+	diag := PodEventsAndLogs{PodLogs: map[string]string{}}
+
+	events, err := c.Clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err == nil {
+		diag.Events = events.Items
+	}
+
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		diag.Failures = append(diag.Failures, fmt.Sprintf("failed to list pods for diagnostics: %v", err))
+		return diag
+	}
+
+	for _, pod := range pods.Items {
+		stream, err := c.Logs(ctx, namespace, pod.Name, LogOptions{TailLines: &tailLines})
+		if err != nil {
+			diag.Failures = append(diag.Failures, fmt.Sprintf("failed to fetch logs for pod %q: %v", pod.Name, err))
+			continue
+		}
+		logs, err := ReadAllLogLines(stream)
+		if err != nil {
+			diag.Failures = append(diag.Failures, fmt.Sprintf("failed to read logs for pod %q: %v", pod.Name, err))
+			continue
+		}
+		diag.PodLogs[pod.Name] = logs
+	}
+
+	return diag
+}
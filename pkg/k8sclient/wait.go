@@ -0,0 +1,155 @@
+package k8sclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// backoffPolicy returns a jittered exponential backoff capped at 30s,
+// matching the poll cadence used elsewhere in the module for flaky cluster
+// operations.
+func backoffPolicy() *backoff.Backoff {
+	return &backoff.Backoff{
+		Min:    250 * time.Millisecond,
+		Max:    30 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+}
+
+// TimeoutError is returned by WaitForRollout/WaitForCondition when the
+// timeout elapses before the resource becomes ready. It carries enough
+// diagnostic context (the last observed resource JSON, recent events, and
+// tail logs of any pods it could find for the namespace) that a CI pipeline
+// can surface actionable failure output without a second manual invocation.
+type TimeoutError struct {
+	Resource  string
+	Namespace string
+	Timeout   time.Duration
+	LastJSON  string
+	Diagnosis PodEventsAndLogs
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for %s in namespace %q to become ready", e.Timeout, e.Resource, e.Namespace)
+}
+
+// WaitForRollout polls a Deployment/StatefulSet/DaemonSet until
+// status.observedGeneration >= metadata.generation and
+// status.readyReplicas == status.replicas, or timeout elapses. It returns
+// the final resource JSON on success, or a *TimeoutError carrying recent pod
+// events and logs on failure.
+func (c *Client) WaitForRollout(ctx context.Context, namespace, kind, name string, timeout time.Duration) (string, error) {
+	mapping, err := c.Mapper.RESTMapping(schema.GroupKind{Kind: kind})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve kind %q: %w", kind, err)
+	}
+
+	var last *unstructured.Unstructured
+	deadline := time.Now().Add(timeout)
+	b := backoffPolicy()
+
+	for {
+		obj, _, err := c.Get(ctx, mapping.GroupVersionKind, namespace, name)
+		if err == nil {
+			last = obj
+			status, err := rolloutStatusFromObject(obj)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse rollout status for %s %q: %w", kind, name, err)
+			}
+			if status.Ready {
+				data, _ := json.Marshal(obj.Object)
+				return string(data), nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return "", c.timeoutError(ctx, namespace, kind, name, timeout, last)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(b.Duration()):
+		}
+	}
+}
+
+// WaitForCondition polls a resource until its .status.conditions[] entry of
+// type conditionType reports status "True", or timeout elapses.
+func (c *Client) WaitForCondition(ctx context.Context, namespace, kind, name, conditionType string, timeout time.Duration) (string, error) {
+	mapping, err := c.Mapper.RESTMapping(schema.GroupKind{Kind: kind})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve kind %q: %w", kind, err)
+	}
+
+	var last *unstructured.Unstructured
+	deadline := time.Now().Add(timeout)
+	b := backoffPolicy()
+
+	for {
+		obj, _, err := c.Get(ctx, mapping.GroupVersionKind, namespace, name)
+		if err == nil {
+			last = obj
+			status, found, err := conditionStatus(obj, conditionType)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse conditions for %s %q: %w", kind, name, err)
+			}
+			if found && status == "True" {
+				data, _ := json.Marshal(obj.Object)
+				return string(data), nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return "", c.timeoutError(ctx, namespace, kind, name, timeout, last)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(b.Duration()):
+		}
+	}
+}
+
+// timeoutError assembles a *TimeoutError, best-effort gathering recent
+// events and pod logs using the resource's own labels as a selector.
+func (c *Client) timeoutError(ctx context.Context, namespace, kind, name string, timeout time.Duration, last *unstructured.Unstructured) *TimeoutError {
+	var lastJSON string
+	var selector string
+	if last != nil {
+		if data, err := json.Marshal(last.Object); err == nil {
+			lastJSON = string(data)
+		}
+		if sel, found, _ := unstructured.NestedStringMap(last.Object, "spec", "selector", "matchLabels"); found {
+			selector = labelsToSelector(sel)
+		}
+	}
+
+	return &TimeoutError{
+		Resource:  fmt.Sprintf("%s/%s", kind, name),
+		Namespace: namespace,
+		Timeout:   timeout,
+		LastJSON:  lastJSON,
+		Diagnosis: c.diagnoseFailingPods(ctx, namespace, selector, 50),
+	}
+}
+
+// labelsToSelector renders a label map as a comma-separated selector string.
+func labelsToSelector(labels map[string]string) string {
+	selector := ""
+	for k, v := range labels {
+		if selector != "" {
+			selector += ","
+		}
+		selector += fmt.Sprintf("%s=%s", k, v)
+	}
+	return selector
+}
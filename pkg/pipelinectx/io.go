@@ -0,0 +1,92 @@
+package pipelinectx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"dagger.io/dagger"
+)
+
+// Write marshals ctx to indented JSON and writes it to dir under its type's
+// conventional file name (e.g. DeliveryContext -> delivery-context.json),
+// returning the resulting file. When signing is configured (see Sign), the
+// caller is expected to sign the returned file separately so Write itself
+// stays a pure, side-effect-free marshal.
+func Write[T named](dir *dagger.Directory, ctx T) (*dagger.File, error) {
+	data, err := json.MarshalIndent(ctx, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", ctx.fileName(), err)
+	}
+	return dir.WithNewFile(ctx.fileName(), string(data)).File(ctx.fileName()), nil
+}
+
+// WriteSigned writes ctx the same way Write does, then additionally signs
+// the resulting file (see Sign) and bundles both into the returned
+// directory under ctx's conventional file name and that name with a ".sig"
+// suffix, so a single directory is all a later stage needs to pass along
+// to verify the context wasn't tampered with in transit. The ".sig" entry
+// is omitted when COSIGN_KEY isn't configured, since signing is optional.
+func WriteSigned[T named](ctx context.Context, client *dagger.Client, dir *dagger.Directory, val T) (*dagger.Directory, error) {
+	file, err := Write(dir, val)
+	if err != nil {
+		return nil, err
+	}
+	result := dir.WithFile(val.fileName(), file)
+
+	sig, err := Sign(ctx, client, file)
+	if err != nil {
+		return nil, err
+	}
+	if sig != nil {
+		result = result.WithFile(val.fileName()+sigSuffix, sig)
+	}
+	return result, nil
+}
+
+// ReadSigned reads T's conventional file out of dir the same way Read does,
+// additionally verifying it against its ".sig" sibling when publicKey is
+// non-empty. Callers that don't need tamper detection (no publicKey
+// configured) get plain Read-equivalent behavior.
+func ReadSigned[T named](ctx context.Context, client *dagger.Client, dir *dagger.Directory, publicKey string) (T, error) {
+	var zero T
+	file := dir.File(zero.fileName())
+
+	if publicKey != "" {
+		sig := dir.File(zero.fileName() + sigSuffix)
+		if err := Verify(ctx, client, file, sig, publicKey); err != nil {
+			var parsed T
+			return parsed, err
+		}
+	}
+
+	return Read[T](ctx, file)
+}
+
+// Read reads file, unmarshals it into T, and validates that its
+// SchemaVersion matches the version this package understands. It returns an
+// error instead of a zero-valued T on any read, parse, or version mismatch
+// so callers can surface a clear failure instead of panicking on a missing
+// or renamed field downstream.
+func Read[T versioned](ctx context.Context, file *dagger.File) (T, error) {
+	var parsed T
+
+	if file == nil {
+		return parsed, fmt.Errorf("context file is required")
+	}
+
+	content, err := file.Contents(ctx)
+	if err != nil {
+		return parsed, fmt.Errorf("failed to read context file: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+		return parsed, fmt.Errorf("failed to parse context file: %w", err)
+	}
+
+	if meta := parsed.versionMeta(); meta.SchemaVersion != SchemaVersion {
+		return parsed, fmt.Errorf("unsupported context schema version %q (expected %q)", meta.SchemaVersion, SchemaVersion)
+	}
+
+	return parsed, nil
+}
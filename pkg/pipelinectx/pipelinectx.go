@@ -0,0 +1,105 @@
+// Package pipelinectx defines the typed, schema-versioned context objects
+// passed between pipeline stages (Build -> Deliver -> Deploy -> Validate),
+// replacing the ad-hoc map[string]interface{} + unchecked type assertions
+// the stage functions used previously. Read validates SchemaVersion before
+// handing a stage its parsed context, so a mismatched or hand-edited file
+// fails loudly instead of panicking on a missing key.
+package pipelinectx
+
+import "time"
+
+// SchemaVersion is the context schema version this package reads and
+// writes. Bump it whenever a context struct's shape changes in a way that
+// isn't backward compatible, and Read will reject files stamped with an
+// older or newer version rather than silently misinterpreting them.
+const SchemaVersion = "v1"
+
+// Meta is embedded in every context type and carries the fields common to
+// all pipeline stages: the schema they were written against, when they were
+// written, the commit they were built from, and the parent context file
+// they were derived from (for tracing a deployment back to its build).
+type Meta struct {
+	SchemaVersion string `json:"schemaVersion"`
+	Timestamp     string `json:"timestamp"`
+	GitSHA        string `json:"gitSha"`
+	Parent        string `json:"parent,omitempty"`
+}
+
+// NewMeta stamps a Meta with the current schema version and timestamp.
+// gitSHA and parent may be empty when unknown.
+func NewMeta(gitSHA, parent string) Meta {
+	return Meta{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		GitSHA:        gitSHA,
+		Parent:        parent,
+	}
+}
+
+// versioned is satisfied by every context type in this package and lets
+// Read validate SchemaVersion generically without per-type boilerplate.
+type versioned interface {
+	versionMeta() Meta
+}
+
+// named is satisfied by every context type in this package and gives Write
+// a stable, per-type default file name without the caller needing to know
+// the pipeline's file-naming convention.
+type named interface {
+	versioned
+	fileName() string
+}
+
+// BuildContext is produced by the Build stage and consumed by Deliver.
+type BuildContext struct {
+	Meta
+	ImageArtifactDigest string `json:"imageArtifactDigest"`
+	ReleaseCandidate     bool  `json:"releaseCandidate"`
+}
+
+func (c BuildContext) versionMeta() Meta { return c.Meta }
+func (c BuildContext) fileName() string  { return "build-context.json" }
+
+// DeliveryContext is produced by the Deliver stage and consumed by Deploy.
+type DeliveryContext struct {
+	Meta
+	ImageReference      string `json:"imageReference"`
+	ChartReference      string `json:"chartReference"`
+	ContainerRepository string `json:"containerRepository"`
+	HelmRepository      string `json:"helmRepository"`
+	ReleaseCandidate    bool   `json:"releaseCandidate"`
+}
+
+func (c DeliveryContext) versionMeta() Meta { return c.Meta }
+func (c DeliveryContext) fileName() string  { return "delivery-context.json" }
+
+// DeploymentContext is produced by the Deploy stage and consumed by
+// Validate and IntegrationTest. PreviousRevision (when set) is the Helm
+// release revision Validate should roll back to if the new deployment
+// fails its health checks.
+type DeploymentContext struct {
+	Meta
+	Endpoint         string `json:"endpoint"`
+	ReleaseName      string `json:"releaseName"`
+	Namespace        string `json:"namespace"`
+	ChartVersion     string `json:"chartVersion"`
+	ImageReference   string `json:"imageReference"`
+	PreviousRevision int    `json:"previousRevision,omitempty"`
+}
+
+func (c DeploymentContext) versionMeta() Meta { return c.Meta }
+func (c DeploymentContext) fileName() string  { return "deployment-context.json" }
+
+// ValidationContext is produced by the Validate stage and consumed by
+// IntegrationTest.
+type ValidationContext struct {
+	Meta
+	ReleaseName     string   `json:"releaseName"`
+	Endpoint        string   `json:"endpoint"`
+	Status          string   `json:"status"`
+	HealthChecks    []string `json:"healthChecks"`
+	ReadinessChecks []string `json:"readinessChecks"`
+}
+
+func (c ValidationContext) versionMeta() Meta { return c.Meta }
+func (c ValidationContext) fileName() string  { return "validation-context.json" }
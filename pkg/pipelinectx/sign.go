@@ -0,0 +1,77 @@
+package pipelinectx
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"dagger.io/dagger"
+)
+
+// cosignImage is the container image used to produce and verify the
+// detached signatures attached to context files.
+const cosignImage = "cgr.dev/chainguard/cosign:latest"
+
+// sigSuffix is appended to a context file's conventional name to derive its
+// detached signature's file name (see WriteSigned/ReadSigned in io.go).
+const sigSuffix = ".sig"
+
+// Sign produces a detached cosign signature for file (named "<file>.sig")
+// when COSIGN_KEY is set in the environment, keeping context files
+// attestable across stages. It is a no-op (returning nil, nil) when
+// COSIGN_KEY is unset, since signing is optional.
+func Sign(ctx context.Context, client *dagger.Client, file *dagger.File) (*dagger.File, error) {
+	keyRef := os.Getenv("COSIGN_KEY")
+	if keyRef == "" {
+		return nil, nil
+	}
+
+	container := client.Container().
+		From(cosignImage).
+		WithMountedFile("/work/context.json", file).
+		WithWorkdir("/work").
+		WithEnvVariable("COSIGN_KEY", keyRef)
+
+	if password := os.Getenv("COSIGN_PASSWORD"); password != "" {
+		container = container.WithSecretVariable("COSIGN_PASSWORD", client.SetSecret("cosign-password", password))
+	}
+
+	container = container.WithExec([]string{
+		"cosign", "sign-blob", "--yes",
+		"--key", keyRef,
+		"--output-signature", "context.json.sig",
+		"context.json",
+	})
+
+	return container.File("/work/context.json.sig"), nil
+}
+
+// Verify checks sig against file using publicKey, returning an error if the
+// signature doesn't verify. Callers should treat a failed verification the
+// same as a missing/tampered context file and refuse to proceed.
+func Verify(ctx context.Context, client *dagger.Client, file *dagger.File, sig *dagger.File, publicKey string) error {
+	if sig == nil {
+		return fmt.Errorf("no signature supplied for context file")
+	}
+	if publicKey == "" {
+		return fmt.Errorf("a cosign public key is required to verify the context signature")
+	}
+
+	container := client.Container().
+		From(cosignImage).
+		WithMountedFile("/work/context.json", file).
+		WithMountedFile("/work/context.json.sig", sig).
+		WithNewFile("/work/cosign.pub", publicKey).
+		WithWorkdir("/work").
+		WithExec([]string{
+			"cosign", "verify-blob",
+			"--key", "cosign.pub",
+			"--signature", "context.json.sig",
+			"context.json",
+		})
+
+	if _, err := container.Stdout(ctx); err != nil {
+		return fmt.Errorf("context signature verification failed: %w", err)
+	}
+	return nil
+}
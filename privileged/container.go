@@ -2,6 +2,8 @@ package cicd
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -10,99 +12,208 @@ import (
 )
 
 // craneImage is the container image used to publish OCI image tarballs.
-// crane (github.com/google/go-containerregistry) can push a tarball directly
-// to a registry without requiring a Docker daemon.
+// crane (github.com/google/go-containerregistry/cmd/crane) can push a
+// tarball directly to a registry without requiring a Docker daemon.
 const craneImage = "gcr.io/go-containerregistry/crane:latest"
 
-// ContainerPush publishes an OCI image tarball to the injected container
-// registry (sourced from CONTAINER_REPOSITORY_URL in local_cicd.env).
+// dockerConfigSecretName is the name of the docker-config-style JSON file
+// read from ~/.cicd-local/secrets/ when authenticating against a private
+// registry. It follows the standard `~/.docker/config.json` "auths" shape:
 //
-// The tarball is the export format produced by `docker save`, `crane export`,
-// or Dagger's Container.Export(). The caller provides the image name and
-// primary tag; the function constructs the full registry reference, pushes
-// the image, and then re-tags it with any additional tags (e.g. "latest" or
-// a floating "major.minor" tag).
+//	{"auths": {"registry.example.com": {"auth": "base64(user:pass)"}}}
+const dockerConfigSecretName = "docker-config.json"
+
+// registryCredentials holds the username/password pair resolved for a given
+// registry host from the injected docker-config secret.
+type registryCredentials struct {
+	username string
+	password string
+}
+
+// loadRegistryAuth reads the docker-config-style secret file (if present)
+// and returns the credentials scoped to registryHost. A missing secret file
+// is not an error: callers fall back to unauthenticated pushes, which is the
+// common case for local/anonymous registries like ttl.sh.
+func loadRegistryAuth(registryHost string) (*registryCredentials, error) {
+	raw, err := LoadSecretFile(dockerConfigSecretName)
+	if err != nil {
+		return nil, nil
+	}
+
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(raw, &dockerConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", dockerConfigSecretName, err)
+	}
+
+	entry, ok := dockerConfig.Auths[registryHost]
+	if !ok {
+		return nil, nil
+	}
+
+	if entry.Username != "" || entry.Password != "" {
+		return &registryCredentials{username: entry.Username, password: entry.Password}, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode auth entry for %s: %w", registryHost, err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed auth entry for %s", registryHost)
+	}
+
+	return &registryCredentials{username: username, password: password}, nil
+}
+
+// withRegistryAuth attaches registry credentials (if any were found for
+// registryHost) to container via Container.WithRegistryAuth.
+func withRegistryAuth(client *dagger.Client, container *dagger.Container, registryHost string) (*dagger.Container, error) {
+	creds, err := loadRegistryAuth(registryHost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry credentials: %w", err)
+	}
+	if creds == nil {
+		return container, nil
+	}
+
+	secret := client.SetSecret(fmt.Sprintf("registry-auth-%s", registryHost), creds.password)
+	return container.WithRegistryAuth(registryHost, creds.username, secret), nil
+}
+
+// ContainerPush publishes an OCI image to the injected container registry
+// (sourced from CONTAINER_REPOSITORY_URL in local_cicd.env).
+//
+// platformImages maps each built platform to the image tarball produced for
+// it (e.g. by Container.Export()). When more than one platform is supplied,
+// the variants are published together as a single multi-arch OCI index via
+// Container.WithPlatformVariant(); a single-entry map publishes a plain
+// single-arch manifest. Private registries are authenticated automatically
+// when a docker-config-style secret is present (see loadRegistryAuth).
 //
 // Parameters:
 //   - ctx: Context for the operation
 //   - client: Dagger client instance
-//   - imageExport: OCI image tarball file (e.g. produced by Container.Export())
+//   - platformImages: Map of platform to the OCI tarball built for it
 //   - imageName: Repository/image name without registry prefix (e.g. "myapp")
 //   - imageTag: Primary tag (e.g. "1.2.3")
 //   - additionalTags: Extra tags to apply after the push (e.g. []string{"latest", "1.2"}).
 //     Pass nil or an empty slice to skip additional tagging.
+//   - force: Forces a re-push even if the registry already has this digest,
+//     replacing the previous CACHE_BUST environment-variable trick.
+//   - sign: When true, signs the published digest with ContainerSign and
+//     attaches a minimal SLSA provenance attestation with ContainerAttest,
+//     giving callers a one-call signed-and-attested publish.
 //
-// Returns the primary published image reference in the form:
-//
-//	<registryURL>/<imageName>:<imageTag>
+// Returns the primary published image reference in the form
+// "<registryURL>/<imageName>:<imageTag>" and the resulting manifest digest,
+// so downstream signing/attestation steps (see ContainerSign/ContainerAttest)
+// have a stable identifier to work from.
 //
 // Example usage:
 //
-//	imageRef, err := cicd.ContainerPush(ctx, client, exportedFile, "myapp", version, []string{"latest"})
+//	imageRef, digest, err := cicd.ContainerPush(ctx, client, platformTarballs, "myapp", version, []string{"latest"}, false, true)
 //	if err != nil {
-//	    return "", fmt.Errorf("container push failed: %w", err)
+//	    return "", "", fmt.Errorf("container push failed: %w", err)
 //	}
 func ContainerPush(
 	ctx context.Context,
 	client *dagger.Client,
-	imageExport *dagger.File,
+	platformImages map[dagger.Platform]*dagger.File,
 	imageName string,
 	imageTag string,
 	additionalTags []string,
-) (string, error) {
-	if imageExport == nil {
-		return "", fmt.Errorf("image export file is required")
+	force bool,
+	sign bool,
+) (string, string, error) {
+	if len(platformImages) == 0 {
+		return "", "", fmt.Errorf("at least one platform image export is required")
 	}
 	if imageName == "" {
-		return "", fmt.Errorf("image name is required")
+		return "", "", fmt.Errorf("image name is required")
 	}
 	if imageTag == "" {
-		return "", fmt.Errorf("image tag is required")
+		return "", "", fmt.Errorf("image tag is required")
 	}
 
 	registryURL, err := GetContainerRepositoryURL()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-
 	registry := strings.TrimRight(registryURL, "/")
+	registryHost := registry
+	if slash := strings.Index(registryHost, "/"); slash != -1 {
+		registryHost = registryHost[:slash]
+	}
 
-	// Build the primary destination reference
 	primaryRef := fmt.Sprintf("%s/%s:%s", registry, imageName, imageTag)
 
-	// Use a single container instance so crane is only pulled once.
-	// The crane image runs as uid/gid 65532 (distroless nonroot). WithFile
-	// defaults to root ownership, so we set the file world-readable (0444)
-	// to avoid "permission denied" when crane tries to open the tarball.
-	// base := client.Container().
-	// 	From(craneImage).
-	// 	WithFile("/export.tar", imageExport, dagger.ContainerWithFileOpts{
-	// 		Permissions: 0444,
-	// 	})
-	ctr := client.Container().Import(imageExport)
-
-	// Push the tarball to the primary tag
-	published, err := ctr.
-		WithEnvVariable("CACHE_BUST", time.Now().String()).
-		Publish(ctx, primaryRef)
+	// A single platform publishes directly; multiple platforms are combined
+	// into one multi-arch index via WithPlatformVariant.
+	var base *dagger.Container
+	for platform, export := range platformImages {
+		variant := client.Container(dagger.ContainerOpts{Platform: platform}).Import(export)
+		variant, err = withRegistryAuth(client, variant, registryHost)
+		if err != nil {
+			return "", "", err
+		}
+		if force {
+			// primaryRef alone is constant across repeated runs against the
+			// same image/tag, so it wouldn't change Dagger's cache key and
+			// a second invocation would silently reuse the prior push.
+			// Mixing in the current time makes each forced call unique.
+			variant = variant.WithEnvVariable("CICD_FORCE_PUSH", fmt.Sprintf("%s-%d", primaryRef, time.Now().UnixNano()))
+		}
+
+		if base == nil {
+			base = variant
+			continue
+		}
+		base = base.WithPlatformVariant(variant)
+	}
+
+	var publishedRef string
+	err = Retry(ctx, func(attempt int) error {
+		var publishErr error
+		publishedRef, publishErr = base.Publish(ctx, primaryRef)
+		return publishErr
+	}, DefaultRetryPolicy())
 	if err != nil {
-		return "", fmt.Errorf("container push failed for %s: %w", primaryRef, err)
+		return "", "", fmt.Errorf("container push failed for %s: %w", primaryRef, err)
+	}
+	digest := publishedRef
+	if _, sha, ok := strings.Cut(publishedRef, "@"); ok {
+		digest = sha
 	}
 
-	// Apply additional tags by copying the manifest within the registry
-	// (crane tag is a cheap registry-side operation; no re-upload needed)
 	for _, tag := range additionalTags {
 		if tag == "" {
 			continue
 		}
 		additionalRef := fmt.Sprintf("%s/%s:%s", registry, imageName, tag)
-		_, err = ctr.
-			WithEnvVariable("CACHE_BUST", time.Now().String()).
-			Publish(ctx, additionalRef)
+		err := Retry(ctx, func(attempt int) error {
+			_, publishErr := base.Publish(ctx, additionalRef)
+			return publishErr
+		}, DefaultRetryPolicy())
 		if err != nil {
-			return "", fmt.Errorf("container tag failed for %s: %w", additionalRef, err)
+			return "", "", fmt.Errorf("container tag failed for %s: %w", additionalRef, err)
+		}
+	}
+
+	if sign {
+		if _, err := ContainerSign(ctx, client, primaryRef, digest); err != nil {
+			return "", "", fmt.Errorf("container push succeeded but signing failed: %w", err)
+		}
+		if _, err := ContainerAttest(ctx, client, primaryRef, digest, slsaPredicateType, slsaProvenancePredicate(primaryRef, digest)); err != nil {
+			return "", "", fmt.Errorf("container push succeeded but attestation failed: %w", err)
 		}
 	}
 
-	return published, nil
+	return primaryRef, digest, nil
 }
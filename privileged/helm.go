@@ -104,21 +104,39 @@ func HelmInstall(
 // HelmPush publishes a packaged Helm chart (.tgz file) to the injected Helm
 // repository URL (sourced from HELM_REPOSITORY_URL in local_cicd.env).
 //
-// The chart tarball is pushed with `helm push` and the function returns the
-// fully-qualified chart reference in the form:
+// The repository mode is auto-detected from the URL scheme - "oci://" pushes
+// via `helm push` to an OCI registry, "http://"/"https://" uploads to a
+// classic ChartMuseum-compatible HTTP(S) chart repository (ChartMuseum,
+// Harbor's chartmuseum backend, JFrog, Nexus) - but can be forced with the
+// HELM_REPOSITORY_TYPE environment variable ("oci", "chartmuseum", or
+// "http"; "chartmuseum" and "http" are treated identically).
 //
-//	<repoURL>/<chartName>:<chartVersion>
+// When provenance is supplied (a .prov file produced upstream by `helm
+// package --sign --key <name> --keyring <keyring>`), it is uploaded
+// alongside the chart so `helm verify` can confirm the package's integrity.
+// Separately, when a cosign key is configured (COSIGN_KEY secret or
+// COSIGN_EXPERIMENTAL=1 for keyless signing), the pushed OCI chart artifact
+// is also cosign-signed; this only applies to OCI pushes, since classic
+// HTTP(S) chart repositories have no concept of an OCI artifact to sign.
 //
 // Parameters:
 //   - ctx: Context for the operation
 //   - client: Dagger client instance
 //   - chartPackage: The packaged chart file (e.g. myapp-1.2.3.tgz)
+//   - provenance: Optional .prov file accompanying chartPackage (can be nil)
 //
-// Returns the published chart reference URL or an error.
+// Environment variables:
+//   - HELM_REPOSITORY_TYPE: Forces "oci", "chartmuseum", or "http" instead of auto-detecting from the URL scheme
+//   - HELM_REPOSITORY_USERNAME / HELM_REPOSITORY_PASSWORD: Basic auth credentials for HTTP(S) chart repositories
+//   - COSIGN_KEY / COSIGN_EXPERIMENTAL: Enable cosign signing of pushed OCI chart artifacts
+//
+// Returns the published chart reference: "oci://registry/chart:version" for
+// OCI repositories, or "https://repo/charts/chart-version.tgz" for HTTP(S)
+// chart repositories.
 //
 // Example usage:
 //
-//	chartRef, err := cicd.HelmPush(ctx, client, chartTgzFile)
+//	chartRef, err := cicd.HelmPush(ctx, client, chartTgzFile, nil)
 //	if err != nil {
 //	    return "", fmt.Errorf("helm push failed: %w", err)
 //	}
@@ -126,6 +144,7 @@ func HelmPush(
 	ctx context.Context,
 	client *dagger.Client,
 	chartPackage *dagger.File,
+	provenance *dagger.File,
 ) (string, error) {
 	if chartPackage == nil {
 		return "", fmt.Errorf("chart package file is required")
@@ -141,6 +160,9 @@ func HelmPush(
 		From("alpine/helm:latest").
 		WithMountedFile("/charts/chart.tgz", chartPackage).
 		WithWorkdir("/charts")
+	if provenance != nil {
+		container = container.WithMountedFile("/charts/chart.tgz.prov", provenance)
+	}
 
 	// Extract the chart name and version from the package so we can construct
 	// the published reference URL after the push.
@@ -166,7 +188,13 @@ func HelmPush(
 		return "", fmt.Errorf("could not determine chart name/version from metadata:\n%s", nameOutput)
 	}
 
-	// Push the chart to the OCI registry
+	if helmRepositoryMode(repoURL) != "oci" {
+		return pushChartMuseum(ctx, client, container, repoURL, chartName, chartVersion, provenance)
+	}
+
+	// Push the chart to the OCI registry. `helm push` automatically uploads
+	// chart.tgz.prov alongside chart.tgz when it's present in the same
+	// directory, so provenance upload needs no extra step here.
 	_, err = container.WithExec([]string{
 		"helm", "push", "/charts/chart.tgz", repoURL,
 	}).Stdout(ctx)
@@ -177,30 +205,55 @@ func HelmPush(
 	// Construct the canonical chart reference:  oci://registry/chartName:version
 	// Strip any trailing slash from repoURL before appending.
 	ref := fmt.Sprintf("%s/%s:%s", strings.TrimRight(repoURL, "/"), chartName, chartVersion)
+
+	if err := signOCIChartIfConfigured(ctx, client, ref); err != nil {
+		return "", err
+	}
+
 	return ref, nil
 }
 
-// HelmUpgrade upgrades an existing Helm release.
-// This function performs a Helm upgrade operation for an already installed release.
+// signOCIChartIfConfigured cosign-signs the pushed OCI chart ref when
+// signing has been configured (a cosign.key secret is present, or
+// COSIGN_EXPERIMENTAL=1 is set for keyless signing). Chart signing is
+// optional, so the absence of either is not an error - HelmPush simply
+// returns the unsigned reference.
+func signOCIChartIfConfigured(ctx context.Context, client *dagger.Client, chartRef string) error {
+	if !SigningConfigured() {
+		return nil
+	}
+
+	if _, err := SignArtifact(ctx, client, chartRef); err != nil {
+		return fmt.Errorf("chart push succeeded but signing failed: %w", err)
+	}
+	return nil
+}
+
+// HelmUpgrade upgrades an existing Helm release, or installs it for the
+// first time. Unlike HelmInstall (which always targets a local chart
+// directory), HelmUpgrade accepts any chart reference Helm itself
+// understands, including OCI references ("oci://registry/chart").
 //
 // Parameters:
 //   - ctx: Context for the operation
 //   - client: Dagger client instance
 //   - releaseName: Name of the Helm release to upgrade
-//   - chartReference: Chart reference (can be a repo/chart or local path)
+//   - chartReference: Chart reference - a repo/chart name, an oci:// reference, or a local path
 //   - namespace: Kubernetes namespace containing the release
+//   - valuesFiles: Optional values.yaml files, applied in order with repeated -f flags (can be nil)
+//   - setValues: Optional key/value pairs applied with --set (can be nil)
 //   - kubeconfig: Dagger secret containing kubeconfig content
 //
 // Environment variables:
-//   - HELM_TIMEOUT: Timeout for helm operations (default: 5m)
+//   - HELM_TIMEOUT: Timeout for helm operations (default: the value returned by GetHelmTimeout)
 //   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
 //
 // Returns the helm upgrade output as a string.
 //
 // Example usage:
 //
-//	kubeconfigSecret, err := privileged.LoadKubeconfig(ctx, client, "")
-//	output, err := privileged.HelmUpgrade(ctx, client, "myapp", "bitnami/nginx", "production", kubeconfigSecret)
+//	kubeconfigSecret, err := privileged.LoadKubeconfig(ctx, client)
+//	output, err := privileged.HelmUpgrade(ctx, client, "myapp", "oci://registry/nginx", "production", nil, nil, kubeconfigSecret)
 //	if err != nil {
 //	    return "", fmt.Errorf("helm upgrade failed: %w", err)
 //	}
@@ -210,6 +263,8 @@ func HelmUpgrade(
 	releaseName string,
 	chartReference string,
 	namespace string,
+	valuesFiles []*dagger.File,
+	setValues map[string]string,
 	kubeconfig *dagger.Secret,
 ) (string, error) {
 	if releaseName == "" {
@@ -232,16 +287,29 @@ func HelmUpgrade(
 
 	// Build helm upgrade command
 	args := []string{
-		"helm", "upgrade",
+		"helm", "upgrade", "--install",
 		releaseName, chartReference,
 		"-n", namespace,
+		"--create-namespace",
+		"--atomic",
+		"--wait",
+	}
+
+	for i, valuesFile := range valuesFiles {
+		mountPath := fmt.Sprintf("/values/%d-values.yaml", i)
+		container = container.WithMountedFile(mountPath, valuesFile)
+		args = append(args, "-f", mountPath)
+	}
+
+	for key, value := range setValues {
+		args = append(args, "--set", fmt.Sprintf("%s=%s", key, value))
 	}
 
 	// Add timeout if specified
 	if timeout := os.Getenv("HELM_TIMEOUT"); timeout != "" {
 		args = append(args, "--timeout", timeout)
 	} else {
-		args = append(args, "--timeout", "5m")
+		args = append(args, "--timeout", GetHelmTimeout())
 	}
 
 	// Add context if specified
@@ -258,6 +326,124 @@ func HelmUpgrade(
 	return output, nil
 }
 
+// HelmTemplate renders a chart's manifests without installing it, using
+// `helm template`. This is useful for a render-only validation step in a
+// pipeline (e.g. piping the output into a policy check) before committing to
+// a real HelmUpgrade.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - releaseName: Release name to render manifests under
+//   - chartReference: Chart reference - a repo/chart name, an oci:// reference, or a local path
+//   - namespace: Kubernetes namespace to render manifests for
+//   - valuesFiles: Optional values.yaml files, applied in order with repeated -f flags (can be nil)
+//   - setValues: Optional key/value pairs applied with --set (can be nil)
+//
+// Returns the rendered manifests as a string.
+func HelmTemplate(
+	ctx context.Context,
+	client *dagger.Client,
+	releaseName string,
+	chartReference string,
+	namespace string,
+	valuesFiles []*dagger.File,
+	setValues map[string]string,
+) (string, error) {
+	if releaseName == "" {
+		return "", fmt.Errorf("release name is required")
+	}
+	if chartReference == "" {
+		return "", fmt.Errorf("chart reference is required")
+	}
+
+	container := client.Container().From("alpine/helm:latest")
+
+	args := []string{"helm", "template", releaseName, chartReference}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+
+	for i, valuesFile := range valuesFiles {
+		mountPath := fmt.Sprintf("/values/%d-values.yaml", i)
+		container = container.WithMountedFile(mountPath, valuesFile)
+		args = append(args, "-f", mountPath)
+	}
+
+	for key, value := range setValues {
+		args = append(args, "--set", fmt.Sprintf("%s=%s", key, value))
+	}
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("helm template failed: %w", err)
+	}
+
+	return output, nil
+}
+
+// HelmUninstall uninstalls a Helm release.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - releaseName: Name of the Helm release to uninstall
+//   - namespace: Kubernetes namespace containing the release
+//   - kubeconfig: Dagger secret containing kubeconfig content
+//   - keepHistory: When true, passes --keep-history so the release's revision
+//     history remains available (e.g. for a later HelmRollback) instead of
+//     being purged along with the release
+//
+// Environment variables:
+//   - HELM_TIMEOUT: Timeout for helm operations (default: the value returned by GetHelmTimeout)
+//   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
+//
+// Returns the helm uninstall output as a string.
+func HelmUninstall(
+	ctx context.Context,
+	client *dagger.Client,
+	releaseName string,
+	namespace string,
+	kubeconfig *dagger.Secret,
+	keepHistory bool,
+) (string, error) {
+	if releaseName == "" {
+		return "", fmt.Errorf("release name is required")
+	}
+	if namespace == "" {
+		return "", fmt.Errorf("namespace is required")
+	}
+	if kubeconfig == nil {
+		return "", fmt.Errorf("kubeconfig secret is required")
+	}
+
+	container := client.Container().
+		From("alpine/helm:latest").
+		WithMountedSecret("/root/.kube/config", kubeconfig)
+
+	args := []string{"helm", "uninstall", releaseName, "-n", namespace}
+	if keepHistory {
+		args = append(args, "--keep-history")
+	}
+
+	if timeout := os.Getenv("HELM_TIMEOUT"); timeout != "" {
+		args = append(args, "--timeout", timeout)
+	} else {
+		args = append(args, "--timeout", GetHelmTimeout())
+	}
+
+	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
+		args = append(args, "--kube-context", kubectlContext)
+	}
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("helm uninstall failed: %w", err)
+	}
+
+	return output, nil
+}
+
 // splitLines splits a string into lines, stripping empty trailing lines.
 func splitLines(s string) []string {
 	return strings.Split(strings.TrimRight(s, "\n"), "\n")
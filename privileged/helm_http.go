@@ -0,0 +1,153 @@
+package cicd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// helmRepositoryMode resolves which upload strategy HelmPush should use for
+// repoURL: "oci" pushes via `helm push` to an OCI registry, anything else
+// uploads to a classic ChartMuseum-compatible HTTP(S) chart repository. The
+// HELM_REPOSITORY_TYPE environment variable overrides auto-detection.
+func helmRepositoryMode(repoURL string) string {
+	if mode := strings.ToLower(os.Getenv("HELM_REPOSITORY_TYPE")); mode != "" {
+		if mode == "chartmuseum" || mode == "http" {
+			return "http"
+		}
+		return mode
+	}
+
+	if strings.HasPrefix(repoURL, "oci://") {
+		return "oci"
+	}
+	return "http"
+}
+
+// pushChartMuseum uploads a packaged chart to a classic HTTP(S) chart
+// repository (ChartMuseum, Harbor's chartmuseum backend, JFrog, Nexus) via
+// its REST API: POST the tarball to /api/charts, then fetch, merge, and
+// re-upload index.yaml so `helm repo update` sees the new entry. When
+// provenance is non-nil, the accompanying .prov file is uploaded to
+// /api/prov so `helm verify` can validate the package afterwards.
+//
+// container must already have the chart mounted at /charts/chart.tgz.
+func pushChartMuseum(
+	ctx context.Context,
+	client *dagger.Client,
+	container *dagger.Container,
+	repoURL string,
+	chartName string,
+	chartVersion string,
+	provenance *dagger.File,
+) (string, error) {
+	repoURL = strings.TrimRight(repoURL, "/")
+
+	username, _ := GetEnvOrSecret("HELM_REPOSITORY_USERNAME", "helm-repository-username")
+	password, hasPassword := loadHelmRepositoryPassword(client)
+
+	// Reference the password through an env var backed by a Dagger secret
+	// rather than interpolating it into the curl args directly, so the
+	// plaintext password never ends up in the pipeline's command line/build
+	// graph. $HELM_REPOSITORY_PASSWORD expands only when the shell actually
+	// runs the command inside the container.
+	authArgs := []string{}
+	if username != "" || hasPassword {
+		authArgs = []string{"-u", fmt.Sprintf("%s:$HELM_REPOSITORY_PASSWORD", username)}
+	}
+	withAuth := func(c *dagger.Container) *dagger.Container {
+		if hasPassword {
+			c = c.WithSecretVariable("HELM_REPOSITORY_PASSWORD", password)
+		}
+		return c
+	}
+
+	httpContainer := withAuth(client.Container().
+		From("curlimages/curl:latest").
+		WithMountedFile("/charts/chart.tgz", container.File("/charts/chart.tgz")).
+		WithWorkdir("/charts"))
+
+	// Upload the chart tarball.
+	uploadArgs := append([]string{"curl", "-sf", "-X", "POST"}, authArgs...)
+	uploadArgs = append(uploadArgs, "--data-binary", "@chart.tgz", repoURL+"/api/charts")
+	if _, err := httpContainer.WithExec([]string{"sh", "-c", shellCommand(uploadArgs)}).Stdout(ctx); err != nil {
+		return "", fmt.Errorf("chartmuseum upload failed for %s-%s: %w", chartName, chartVersion, err)
+	}
+
+	if provenance != nil {
+		provContainer := withAuth(client.Container().
+			From("curlimages/curl:latest").
+			WithMountedFile("/charts/chart.tgz.prov", provenance).
+			WithWorkdir("/charts"))
+		provUploadArgs := append([]string{"curl", "-sf", "-X", "POST"}, authArgs...)
+		provUploadArgs = append(provUploadArgs, "--data-binary", "@chart.tgz.prov", repoURL+"/api/prov")
+		if _, err := provContainer.WithExec([]string{"sh", "-c", shellCommand(provUploadArgs)}).Stdout(ctx); err != nil {
+			return "", fmt.Errorf("chartmuseum provenance upload failed for %s-%s: %w", chartName, chartVersion, err)
+		}
+	}
+
+	// Fetch the existing index.yaml with curl (alpine/helm doesn't ship
+	// curl), then merge the new entry in with `helm repo index --merge` in
+	// a separate alpine/helm container, and re-upload it.
+	fetchArgs := append([]string{"curl", "-sf", "-o", "index.yaml"}, authArgs...)
+	fetchArgs = append(fetchArgs, repoURL+"/index.yaml")
+
+	fetchContainer := withAuth(client.Container().
+		From("curlimages/curl:latest").
+		WithWorkdir("/charts")).
+		WithExec([]string{"sh", "-c", curlCommand(fetchArgs)})
+
+	mergeContainer := client.Container().
+		From("alpine/helm:latest").
+		WithMountedFile("/charts/chart.tgz", container.File("/charts/chart.tgz")).
+		WithMountedFile("/charts/index.yaml", fetchContainer.File("/charts/index.yaml")).
+		WithWorkdir("/charts").
+		WithExec([]string{"helm", "repo", "index", ".", "--merge", "index.yaml", "--url", repoURL})
+
+	indexUploadArgs := append([]string{"curl", "-sf", "-X", "PUT"}, authArgs...)
+	indexUploadArgs = append(indexUploadArgs, "--data-binary", "@index.yaml", repoURL+"/api/index.yaml")
+
+	finalContainer := withAuth(client.Container().
+		From("curlimages/curl:latest").
+		WithMountedFile("/charts/index.yaml", mergeContainer.File("/charts/index.yaml")).
+		WithWorkdir("/charts"))
+	if _, err := finalContainer.WithExec([]string{"sh", "-c", shellCommand(indexUploadArgs)}).Stdout(ctx); err != nil {
+		return "", fmt.Errorf("chartmuseum index.yaml re-upload failed: %w", err)
+	}
+
+	return fmt.Sprintf("%s/charts/%s-%s.tgz", repoURL, chartName, chartVersion), nil
+}
+
+// loadHelmRepositoryPassword loads the chart repository password as a
+// Dagger secret (sourced from HELM_REPOSITORY_PASSWORD or the
+// helm-repository-password secret file), so it can be threaded through
+// WithSecretVariable instead of baked in plaintext as a curl argument.
+// Returns false when no password is configured.
+func loadHelmRepositoryPassword(client *dagger.Client) (*dagger.Secret, bool) {
+	password, err := GetEnvOrSecret("HELM_REPOSITORY_PASSWORD", "helm-repository-password")
+	if err != nil || password == "" {
+		return nil, false
+	}
+	return client.SetSecret("helm-repository-password", password), true
+}
+
+// shellCommand joins a curl invocation into a single shell command so it
+// can be run with `sh -c`, letting $HELM_REPOSITORY_PASSWORD expand from
+// the container's environment instead of appearing literally in the args.
+func shellCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = fmt.Sprintf("%q", a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// curlCommand is shellCommand, additionally tolerating a missing
+// index.yaml on a brand-new repository (curl exits non-zero on 404, which
+// would otherwise fail the whole chain for the very first chart pushed).
+func curlCommand(args []string) string {
+	return shellCommand(args) + " || true"
+}
@@ -0,0 +1,219 @@
+package cicd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"dagger.io/dagger"
+)
+
+// HelmRollback rolls a release back to a prior revision using `helm
+// rollback`. Passing revision 0 rolls back to the immediately preceding
+// release, matching Helm's own convention.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - releaseName: Name of the Helm release to roll back
+//   - namespace: Kubernetes namespace containing the release
+//   - revision: Revision to roll back to, or 0 for the previous revision
+//   - kubeconfig: Dagger secret containing kubeconfig content
+//
+// Environment variables:
+//   - HELM_TIMEOUT: Timeout for helm operations (default: the value returned by GetHelmTimeout)
+//   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
+//
+// Returns the helm rollback output as a string.
+//
+// Example usage:
+//
+//	output, err := cicd.HelmRollback(ctx, client, "myapp", "production", 0, kubeconfigSecret)
+//	if err != nil {
+//	    return "", fmt.Errorf("helm rollback failed: %w", err)
+//	}
+func HelmRollback(
+	ctx context.Context,
+	client *dagger.Client,
+	releaseName string,
+	namespace string,
+	revision int,
+	kubeconfig *dagger.Secret,
+) (string, error) {
+	if releaseName == "" {
+		return "", fmt.Errorf("release name is required")
+	}
+	if namespace == "" {
+		return "", fmt.Errorf("namespace is required")
+	}
+	if kubeconfig == nil {
+		return "", fmt.Errorf("kubeconfig secret is required")
+	}
+
+	container := client.Container().
+		From("alpine/helm:latest").
+		WithMountedSecret("/root/.kube/config", kubeconfig)
+
+	args := []string{"helm", "rollback", releaseName}
+	if revision > 0 {
+		args = append(args, fmt.Sprintf("%d", revision))
+	}
+	args = append(args, "-n", namespace, "--wait")
+
+	if timeout := os.Getenv("HELM_TIMEOUT"); timeout != "" {
+		args = append(args, "--timeout", timeout)
+	} else {
+		args = append(args, "--timeout", GetHelmTimeout())
+	}
+	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
+		args = append(args, "--kube-context", kubectlContext)
+	}
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("helm rollback failed: %w", err)
+	}
+	return output, nil
+}
+
+// ReleaseRevision is one entry of `helm history`'s output, describing a
+// single revision of a release.
+type ReleaseRevision struct {
+	Revision    int    `json:"revision"`
+	Updated     string `json:"updated"`
+	Status      string `json:"status"`
+	Chart       string `json:"chart"`
+	AppVersion  string `json:"app_version"`
+	Description string `json:"description"`
+}
+
+// HelmHistory returns the revision history of a release, parsed from `helm
+// history -o json`, most recent revision last (Helm's own ordering).
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - releaseName: Name of the Helm release
+//   - namespace: Kubernetes namespace containing the release
+//   - kubeconfig: Dagger secret containing kubeconfig content
+//
+// Returns the parsed revision history.
+func HelmHistory(
+	ctx context.Context,
+	client *dagger.Client,
+	releaseName string,
+	namespace string,
+	kubeconfig *dagger.Secret,
+) ([]ReleaseRevision, error) {
+	if releaseName == "" {
+		return nil, fmt.Errorf("release name is required")
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if kubeconfig == nil {
+		return nil, fmt.Errorf("kubeconfig secret is required")
+	}
+
+	container := client.Container().
+		From("alpine/helm:latest").
+		WithMountedSecret("/root/.kube/config", kubeconfig)
+
+	args := []string{"helm", "history", releaseName, "-n", namespace, "-o", "json"}
+	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
+		args = append(args, "--kube-context", kubectlContext)
+	}
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("helm history failed: %w", err)
+	}
+
+	var history []ReleaseRevision
+	if err := json.Unmarshal([]byte(output), &history); err != nil {
+		return nil, fmt.Errorf("failed to parse helm history output: %w", err)
+	}
+	return history, nil
+}
+
+// ReleaseStatus is the subset of `helm status -o json`'s output this
+// package cares about: enough to tell whether a release is healthy and
+// where it's pointed.
+type ReleaseStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status      string `json:"status"`
+		Description string `json:"description"`
+	} `json:"info"`
+}
+
+// HelmStatus returns the current status of a release, parsed from `helm
+// status -o json`.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - releaseName: Name of the Helm release
+//   - namespace: Kubernetes namespace containing the release
+//   - kubeconfig: Dagger secret containing kubeconfig content
+//
+// Returns the parsed release status.
+func HelmStatus(
+	ctx context.Context,
+	client *dagger.Client,
+	releaseName string,
+	namespace string,
+	kubeconfig *dagger.Secret,
+) (*ReleaseStatus, error) {
+	if releaseName == "" {
+		return nil, fmt.Errorf("release name is required")
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if kubeconfig == nil {
+		return nil, fmt.Errorf("kubeconfig secret is required")
+	}
+
+	container := client.Container().
+		From("alpine/helm:latest").
+		WithMountedSecret("/root/.kube/config", kubeconfig)
+
+	args := []string{"helm", "status", releaseName, "-n", namespace, "-o", "json"}
+	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
+		args = append(args, "--kube-context", kubectlContext)
+	}
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("helm status failed: %w", err)
+	}
+
+	var status ReleaseStatus
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse helm status output: %w", err)
+	}
+	return &status, nil
+}
+
+// PreviousSuccessfulRevision scans history (as returned by HelmHistory) for
+// the most recent revision whose status is "deployed" or "superseded",
+// excluding the current (most recent) revision. It returns 0 if no prior
+// successful revision is found, which HelmRollback treats as "roll back to
+// whatever Helm considers previous".
+func PreviousSuccessfulRevision(history []ReleaseRevision) int {
+	if len(history) < 2 {
+		return 0
+	}
+
+	for i := len(history) - 2; i >= 0; i-- {
+		switch history[i].Status {
+		case "deployed", "superseded":
+			return history[i].Revision
+		}
+	}
+	return 0
+}
@@ -3,13 +3,40 @@ package cicd
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
+	"strings"
 
 	"dagger.io/dagger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/jpbarto/cicd-local/pkg/k8sclient"
 )
 
-// KubectlApply applies Kubernetes manifests using kubectl.
-// This function executes kubectl apply with the provided manifests directory.
+// kubeClientFor decodes the injected kubeconfig secret and builds a native
+// k8sclient.Client from it. This is the shared entry point used by
+// KubectlApply, KubectlGet, KubectlPortForward, and KubectlLogs so that each
+// call no longer has to pull and run the bitnami/kubectl container image.
+func kubeClientFor(ctx context.Context, client *dagger.Client) (*k8sclient.Client, []byte, error) {
+	kubeconfig, err := LoadKubeconfig(ctx, client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+
+	plaintext, err := kubeconfig.Plaintext(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read kubeconfig secret: %w", err)
+	}
+
+	kc, err := k8sclient.New([]byte(plaintext))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build kubernetes client: %w", err)
+	}
+
+	return kc, []byte(plaintext), nil
+}
+
+// KubectlApply applies Kubernetes manifests using a server-side apply against
+// the native k8sclient subsystem (see pkg/k8sclient).
 //
 // Parameters:
 //   - ctx: Context for the operation
@@ -17,10 +44,7 @@ import (
 //   - manifestsDir: Directory containing Kubernetes YAML manifests
 //   - namespace: Kubernetes namespace to apply to (optional, uses manifest default if empty)
 //
-// Environment variables:
-//   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
-//
-// Returns the kubectl apply output as a string.
+// Returns the combined apply output (one line per applied resource) as a string.
 //
 // Example usage:
 //
@@ -38,50 +62,64 @@ func KubectlApply(
 		return "", fmt.Errorf("manifests directory is required")
 	}
 
-	kubeconfig, err := GetKubeconfigSecret(ctx, client)
+	kc, _, err := kubeClientFor(ctx, client)
 	if err != nil {
-		return "", fmt.Errorf("failed to get kubeconfig: %w", err)
+		return "", err
 	}
 
-	// Start with kubectl container
-	container := client.Container().
-		From("bitnami/kubectl:latest").
-		WithMountedDirectory("/manifests", manifestsDir).
-		WithMountedSecret("/root/.kube/config", kubeconfig)
+	entries, err := manifestsDir.Entries(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list manifests directory: %w", err)
+	}
 
-	// Build kubectl command
-	args := []string{"kubectl", "apply", "-f", "/manifests"}
+	var output strings.Builder
+	for _, entry := range entries {
+		if !isManifestFile(entry) {
+			continue
+		}
 
-	// Add namespace if specified
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
+		contents, err := manifestsDir.File(entry).Contents(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to read manifest %q: %w", entry, err)
+		}
 
-	// Add context if specified in environment
-	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
-		args = append(args, "--context", kubectlContext)
-	}
+		objs, err := decodeManifestObjects(contents)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse manifest %q: %w", entry, err)
+		}
 
-	// Execute kubectl apply
-	output, err := container.WithExec(args).Stdout(ctx)
-	if err != nil {
-		return "", fmt.Errorf("kubectl apply failed: %w", err)
+		for _, obj := range objs {
+			if namespace != "" && obj.GetNamespace() == "" {
+				obj.SetNamespace(namespace)
+			}
+
+			var applied *unstructured.Unstructured
+			err := Retry(ctx, func(attempt int) error {
+				var applyErr error
+				applied, applyErr = kc.Apply(ctx, obj, false)
+				if applyErr != nil && k8sclient.IsConflict(applyErr) {
+					applied, applyErr = kc.Apply(ctx, obj, true)
+				}
+				return applyErr
+			}, DefaultRetryPolicy())
+			if err != nil {
+				return "", fmt.Errorf("kubectl apply failed for %s/%s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			fmt.Fprintf(&output, "%s/%s configured\n", strings.ToLower(applied.GetKind()), applied.GetName())
+		}
 	}
 
-	return output, nil
+	return output.String(), nil
 }
 
-// KubectlGet retrieves a Kubernetes resource and returns it as JSON.
-// This function executes kubectl get with the specified resource and namespace.
+// KubectlGet retrieves a Kubernetes resource and returns it as JSON, using
+// the native k8sclient subsystem instead of shelling out to kubectl.
 //
 // Parameters:
 //   - ctx: Context for the operation
 //   - client: Dagger client instance
 //   - namespace: Kubernetes namespace containing the resource
-//   - resourceName: Resource to get (e.g., "pod/mypod", "deployment/myapp", "service/mysvc")
-//
-// Environment variables:
-//   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
+//   - resourceName: Resource to get in "kind/name" form (e.g., "pod/mypod", "deployment/myapp")
 //
 // Returns the resource as JSON string.
 //
@@ -104,67 +142,63 @@ func KubectlGet(
 		return "", fmt.Errorf("resource name is required")
 	}
 
-	kubeconfig, err := GetKubeconfigSecret(ctx, client)
+	kind, name, err := splitKindName(resourceName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get kubeconfig: %w", err)
+		return "", err
 	}
 
-	// Start with kubectl container
-	container := client.Container().
-		From("bitnami/kubectl:latest").
-		WithMountedSecret("/root/.kube/config", kubeconfig)
-
-	// Build kubectl command with JSON output
-	args := []string{"kubectl", "get", resourceName, "-n", namespace, "-o", "json"}
-
-	// Add context if specified in environment
-	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
-		args = append(args, "--context", kubectlContext)
+	kc, _, err := kubeClientFor(ctx, client)
+	if err != nil {
+		return "", err
 	}
 
-	// Execute kubectl get
-	output, err := container.WithExec(args).Stdout(ctx)
+	var jsonOut string
+	err = Retry(ctx, func(attempt int) error {
+		var getErr error
+		_, jsonOut, getErr = kc.GetByKind(ctx, namespace, kind, name)
+		return getErr
+	}, DefaultRetryPolicy())
 	if err != nil {
+		if k8sclient.IsNotFound(err) {
+			return "", fmt.Errorf("kubectl get failed: %s %q not found in namespace %q", kind, name, namespace)
+		}
 		return "", fmt.Errorf("kubectl get failed: %w", err)
 	}
 
-	return output, nil
+	return jsonOut, nil
 }
 
-// KubectlPortForward creates a port-forwarding tunnel to a Kubernetes resource.
-// This function returns a Service that can be used by other Dagger functions to connect
-// to the forwarded port. The port forwarding runs in the background as a service.
+// KubectlPortForward creates a port-forwarding tunnel to a pod using the
+// native k8sclient subsystem (k8s.io/client-go/tools/portforward over an
+// SPDY dialer) rather than an exec'd `kubectl port-forward` process.
 //
 // Parameters:
 //   - ctx: Context for the operation
 //   - client: Dagger client instance
 //   - namespace: Kubernetes namespace containing the resource
-//   - resourceName: Resource to forward to (e.g., "pod/mypod", "deployment/myapp", "service/mysvc")
-//   - ports: Port mapping in format "localPort:remotePort" (e.g., "8080:80", "3000:3000")
-//
-// Environment variables:
-//   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
+//   - resourceName: Pod to forward to (e.g., "pod/mypod")
+//   - ports: Port mapping in format "localPort:remotePort" (e.g., "8080:80")
 //
-// Returns a Dagger Service that forwards the specified ports.
+// Returns the *k8sclient.PortForwarder controlling the tunnel: close its
+// StopCh to tear the forward down, or wait on ReadyCh/ErrCh to observe when
+// it comes up or stops. The forward itself runs in a background goroutine
+// for the lifetime of the calling process, not inside a Dagger container,
+// so it cannot be returned as a Dagger Service.
 //
 // Example usage:
 //
-//	portForwardSvc, err := cicd.KubectlPortForward(ctx, client, "default", "pod/mypod", "8080:80")
+//	fw, err := cicd.KubectlPortForward(ctx, client, "default", "pod/mypod", "8080:80")
 //	if err != nil {
 //	    return err
 //	}
-//	// Use the service in another container
-//	testContainer := client.Container().
-//	    From("curlimages/curl:latest").
-//	    WithServiceBinding("app", portForwardSvc).
-//	    WithExec([]string{"curl", "http://app:8080/health"})
+//	defer close(fw.StopCh)
 func KubectlPortForward(
 	ctx context.Context,
 	client *dagger.Client,
 	namespace string,
 	resourceName string,
 	ports string,
-) (*dagger.Service, error) {
+) (*k8sclient.PortForwarder, error) {
 	if namespace == "" {
 		return nil, fmt.Errorf("namespace is required")
 	}
@@ -175,61 +209,30 @@ func KubectlPortForward(
 		return nil, fmt.Errorf("ports are required (format: localPort:remotePort)")
 	}
 
-	kubeconfig, err := GetKubeconfigSecret(ctx, client)
+	_, kubeconfig, err := kubeClientFor(ctx, client)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+		return nil, err
 	}
 
-	// Start with kubectl container
-	container := client.Container().
-		From("bitnami/kubectl:latest").
-		WithMountedSecret("/root/.kube/config", kubeconfig)
-
-	// Build kubectl port-forward command
-	args := []string{
-		"kubectl", "port-forward",
-		resourceName,
-		ports,
-		"-n", namespace,
-		"--address", "0.0.0.0", // Listen on all interfaces so Dagger can access it
+	kind, podName, err := splitKindName(resourceName)
+	if err != nil {
+		return nil, err
 	}
-
-	// Add context if specified in environment
-	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
-		args = append(args, "--context", kubectlContext)
+	if !strings.EqualFold(kind, "pod") {
+		return nil, fmt.Errorf("port-forward only supports pod/<name> references, got %q", resourceName)
 	}
 
-	// Extract the local port from the ports string (e.g., "8080:80" -> 8080)
-	localPort := ports
-	for i, c := range ports {
-		if c == ':' {
-			localPort = ports[:i]
-			break
-		}
+	kc, err := k8sclient.New(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubernetes client: %w", err)
 	}
 
-	// Create and return the service
-	// The service will run kubectl port-forward in the background
-	service := container.
-		WithExec(args).
-		WithExposedPort(parsePort(localPort)).
-		AsService()
-
-	return service, nil
-}
-
-// parsePort converts a port string to an integer for WithExposedPort
-func parsePort(portStr string) int {
-	port := 0
-	for _, c := range portStr {
-		if c >= '0' && c <= '9' {
-			port = port*10 + int(c-'0')
-		}
-	}
-	if port == 0 {
-		return 8080 // default fallback
+	fw, err := kc.PortForward(ctx, kubeconfig, namespace, podName, []string{ports}, io.Discard, io.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("kubectl port-forward failed: %w", err)
 	}
-	return port
+
+	return fw, nil
 }
 
 // KubectlLogs retrieves log lines from a pod. The kubeconfig is sourced
@@ -242,9 +245,6 @@ func parsePort(portStr string) int {
 //   - podName: Name of the pod (e.g. "myapp-7d6b9f-xkj2p")
 //   - lines: Maximum number of log lines to return (passed as --tail)
 //
-// Environment variables:
-//   - KUBECTL_CONTEXT: Kubernetes context to use (optional)
-//
 // Returns the log output as a string or an error.
 //
 // Example usage:
@@ -270,27 +270,22 @@ func KubectlLogs(
 		return "", fmt.Errorf("lines must be greater than 0")
 	}
 
-	kubeconfig, err := GetKubeconfigSecret(ctx, client)
+	kc, _, err := kubeClientFor(ctx, client)
 	if err != nil {
-		return "", fmt.Errorf("failed to get kubeconfig: %w", err)
-	}
-
-	args := []string{
-		"kubectl", "logs",
-		podName,
-		"-n", namespace,
-		"--tail", fmt.Sprintf("%d", lines),
+		return "", err
 	}
 
-	if kubectlContext := os.Getenv("KUBECTL_CONTEXT"); kubectlContext != "" {
-		args = append(args, "--context", kubectlContext)
-	}
-
-	output, err := client.Container().
-		From("bitnami/kubectl:latest").
-		WithMountedSecret("/root/.kube/config", kubeconfig).
-		WithExec(args).
-		Stdout(ctx)
+	tail := int64(lines)
+	var output string
+	err = Retry(ctx, func(attempt int) error {
+		stream, logsErr := kc.Logs(ctx, namespace, podName, k8sclient.LogOptions{TailLines: &tail})
+		if logsErr != nil {
+			return logsErr
+		}
+		var readErr error
+		output, readErr = k8sclient.ReadAllLogLines(stream)
+		return readErr
+	}, DefaultRetryPolicy())
 	if err != nil {
 		return "", fmt.Errorf("kubectl logs failed for pod %q in namespace %q: %w", podName, namespace, err)
 	}
@@ -0,0 +1,52 @@
+package cicd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// isManifestFile reports whether a directory entry looks like a Kubernetes
+// YAML manifest.
+func isManifestFile(name string) bool {
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")
+}
+
+// decodeManifestObjects splits a multi-document YAML file into individual
+// unstructured objects, skipping empty documents (e.g. trailing "---").
+// Returns an error if any document is malformed, rather than silently
+// dropping the rest of the file.
+func decodeManifestObjects(contents string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(contents), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifest document: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// splitKindName splits a "kind/name" resource reference as accepted by
+// kubectl (e.g. "pod/mypod", "deployment/myapp").
+func splitKindName(ref string) (kind string, name string, err error) {
+	kind, name, ok := strings.Cut(ref, "/")
+	if !ok || kind == "" || name == "" {
+		return "", "", fmt.Errorf("resource reference %q must be in kind/name form", ref)
+	}
+	return kind, name, nil
+}
@@ -0,0 +1,147 @@
+package cicd
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpillora/backoff"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// RetryPolicy configures Retry's jittered exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts caps the number of attempts (including the first). Zero
+	// means use DefaultRetryPolicy's value.
+	MaxAttempts int
+	// MaxElapsed caps the total time spent retrying, measured from the
+	// first attempt. Zero means use DefaultRetryPolicy's value.
+	MaxElapsed time.Duration
+	// Min and Max bound each individual backoff sleep.
+	Min time.Duration
+	Max time.Duration
+}
+
+// DefaultRetryPolicy returns the policy Retry uses when callers don't
+// override it, reading CICD_RETRY_MAX_ATTEMPTS / CICD_RETRY_MAX_ELAPSED from
+// the environment so pipelines running against flaky shared clusters can
+// tune retry behavior without a code change.
+func DefaultRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		MaxAttempts: 5,
+		MaxElapsed:  2 * time.Minute,
+		Min:         250 * time.Millisecond,
+		Max:         30 * time.Second,
+	}
+
+	if v := os.Getenv("CICD_RETRY_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			policy.MaxAttempts = n
+		}
+	}
+	if v := os.Getenv("CICD_RETRY_MAX_ELAPSED"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			policy.MaxElapsed = d
+		}
+	}
+
+	return policy
+}
+
+// Retry calls op repeatedly, with a jittered exponential backoff between
+// attempts, until op returns nil, a non-transient error, policy.MaxAttempts
+// is reached, or policy.MaxElapsed has elapsed. op receives the 1-based
+// attempt number so it can log progress.
+//
+// Example usage:
+//
+//	err := cicd.Retry(ctx, func(attempt int) error {
+//	    _, err := cicd.KubectlApply(ctx, client, manifestsDir, namespace)
+//	    return err
+//	}, cicd.DefaultRetryPolicy())
+func Retry(ctx context.Context, op func(attempt int) error, policy RetryPolicy) error {
+	if policy.MaxAttempts <= 0 {
+		policy = DefaultRetryPolicy()
+	}
+
+	b := &backoff.Backoff{
+		Min:    policy.Min,
+		Max:    policy.Max,
+		Factor: 2,
+		Jitter: true,
+	}
+	start := time.Now()
+
+	var lastErr error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		lastErr = op(attempt)
+		if lastErr == nil {
+			return nil
+		}
+		if !IsTransient(lastErr) {
+			return lastErr
+		}
+		if attempt == policy.MaxAttempts || time.Since(start) >= policy.MaxElapsed {
+			return lastErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.Duration()):
+		}
+	}
+
+	return lastErr
+}
+
+// IsTransient reports whether err looks like a transient failure worth
+// retrying: registry 5xx / TOOMANYREQUESTS responses, Kubernetes
+// ServerTimeout/TooManyRequests/connection-refused errors, or a dropped
+// connection mid-pull (io.EOF/io.ErrUnexpectedEOF). It inspects both typed
+// Kubernetes API errors (via kubeerrors) and wrapped error strings, since
+// many of the errors this package returns have already been through
+// fmt.Errorf("...: %w"). Deliberately excluded: "context canceled", since
+// that means the caller asked to stop, not that the operation can be
+// retried - the retry loop already returns ctx.Err() immediately via its
+// own ctx.Done() check rather than spending retry budget on it.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if kubeerrors.IsServerTimeout(err) || kubeerrors.IsTooManyRequests(err) || kubeerrors.IsTimeout(err) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, marker := range transientMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+
+	return eofPattern.MatchString(msg)
+}
+
+// eofPattern matches a standalone "eof" (as in io.EOF/io.ErrUnexpectedEOF's
+// error text) without also matching unrelated words that merely contain
+// the substring "eof", unlike a plain strings.Contains check.
+var eofPattern = regexp.MustCompile(`\beof\b`)
+
+// transientMarkers are substrings of error messages that indicate a
+// transient failure when a typed error isn't available (e.g. the error
+// crossed a container/process boundary and only a string survived).
+var transientMarkers = []string{
+	"toomanyrequests",
+	"too many requests",
+	"connection refused",
+	"i/o timeout",
+	"temporary failure",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
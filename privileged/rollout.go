@@ -0,0 +1,114 @@
+package cicd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dagger.io/dagger"
+)
+
+// KubectlRolloutStatus polls a Deployment/StatefulSet/DaemonSet until its
+// rollout completes (observedGeneration has caught up and readyReplicas
+// equals the desired replica count) or timeout elapses.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - namespace: Kubernetes namespace containing the resource
+//   - resourceRef: Resource to wait on in "kind/name" form (e.g., "deployment/myapp")
+//   - timeout: Maximum time to wait before giving up
+//
+// Returns the final resource JSON on success. On timeout, returns a
+// *k8sclient.TimeoutError carrying recent pod events and the last 50 log
+// lines from any pods it could find, so CI pipelines can produce actionable
+// failure output without a second manual invocation.
+//
+// Example usage:
+//
+//	resultJSON, err := cicd.KubectlRolloutStatus(ctx, client, "default", "deployment/myapp", 2*time.Minute)
+//	if err != nil {
+//	    return "", fmt.Errorf("rollout did not complete: %w", err)
+//	}
+func KubectlRolloutStatus(
+	ctx context.Context,
+	client *dagger.Client,
+	namespace string,
+	resourceRef string,
+	timeout time.Duration,
+) (string, error) {
+	if namespace == "" {
+		return "", fmt.Errorf("namespace is required")
+	}
+
+	kind, name, err := splitKindName(resourceRef)
+	if err != nil {
+		return "", err
+	}
+
+	kc, _, err := kubeClientFor(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := kc.WaitForRollout(ctx, namespace, kind, name, timeout)
+	if err != nil {
+		return "", fmt.Errorf("kubectl rollout status failed for %s: %w", resourceRef, err)
+	}
+
+	return result, nil
+}
+
+// WaitForCondition polls a resource until its status.conditions[] entry of
+// type conditionType reports "True", or timeout elapses. This is the native
+// equivalent of `kubectl wait --for=condition=<type>`.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - namespace: Kubernetes namespace containing the resource
+//   - resourceRef: Resource to wait on in "kind/name" form
+//   - conditionType: Condition type to wait for (e.g. "Available", "Ready")
+//   - timeout: Maximum time to wait before giving up
+//
+// Returns the final resource JSON on success, or a *k8sclient.TimeoutError
+// with diagnostic pod events/logs on timeout.
+//
+// Example usage:
+//
+//	resultJSON, err := cicd.WaitForCondition(ctx, client, "default", "deployment/myapp", "Available", time.Minute)
+//	if err != nil {
+//	    return "", fmt.Errorf("condition never became true: %w", err)
+//	}
+func WaitForCondition(
+	ctx context.Context,
+	client *dagger.Client,
+	namespace string,
+	resourceRef string,
+	conditionType string,
+	timeout time.Duration,
+) (string, error) {
+	if namespace == "" {
+		return "", fmt.Errorf("namespace is required")
+	}
+	if conditionType == "" {
+		return "", fmt.Errorf("condition type is required")
+	}
+
+	kind, name, err := splitKindName(resourceRef)
+	if err != nil {
+		return "", err
+	}
+
+	kc, _, err := kubeClientFor(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := kc.WaitForCondition(ctx, namespace, kind, name, conditionType, timeout)
+	if err != nil {
+		return "", fmt.Errorf("wait for condition %q failed for %s: %w", conditionType, resourceRef, err)
+	}
+
+	return result, nil
+}
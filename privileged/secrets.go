@@ -1,4 +1,4 @@
-package privileged
+package cicd
 
 import (
 	"context"
@@ -83,7 +83,7 @@ func GetHelmTimeout() string {
 //
 // Example usage:
 //
-//	kubeconfigSecret, err := privileged.LoadKubeconfig(ctx, client)
+//	kubeconfigSecret, err := cicd.LoadKubeconfig(ctx, client)
 //	if err != nil {
 //	    return err
 //	}
@@ -105,7 +105,7 @@ func LoadKubeconfig(ctx context.Context, client *dagger.Client) (*dagger.Secret,
 //
 // Example usage:
 //
-//	secretPath, err := privileged.GetSecretPath("api-token")
+//	secretPath, err := cicd.GetSecretPath("api-token")
 //	if err != nil {
 //	    return err
 //	}
@@ -130,7 +130,7 @@ func GetSecretPath(secretName string) (string, error) {
 //
 // Example usage:
 //
-//	content, err := privileged.LoadSecretFile("api-token")
+//	content, err := cicd.LoadSecretFile("api-token")
 //	if err != nil {
 //	    return err
 //	}
@@ -159,7 +159,7 @@ func LoadSecretFile(secretName string) ([]byte, error) {
 //
 // Example usage:
 //
-//	apiToken, err := privileged.LoadSecretAsDaggerSecret(client, "api-token")
+//	apiToken, err := cicd.LoadSecretAsDaggerSecret(client, "api-token")
 //	if err != nil {
 //	    return err
 //	}
@@ -183,7 +183,7 @@ func LoadSecretAsDaggerSecret(client *dagger.Client, secretName string) (*dagger
 //
 // Example usage:
 //
-//	apiKey, err := privileged.GetEnvOrSecret("API_KEY", "api-key")
+//	apiKey, err := cicd.GetEnvOrSecret("API_KEY", "api-key")
 //	if err != nil {
 //	    return err
 //	}
@@ -201,3 +201,17 @@ func GetEnvOrSecret(envVar, secretName string) (string, error) {
 
 	return string(content), nil
 }
+
+// GetContainerRepositoryURL returns the configured container registry URL,
+// sourced from CONTAINER_REPOSITORY_URL (see local_cicd.env) or the
+// container-repository-url secret file.
+func GetContainerRepositoryURL() (string, error) {
+	return GetEnvOrSecret("CONTAINER_REPOSITORY_URL", "container-repository-url")
+}
+
+// GetHelmRepositoryURL returns the configured Helm chart repository URL,
+// sourced from HELM_REPOSITORY_URL (see local_cicd.env) or the
+// helm-repository-url secret file.
+func GetHelmRepositoryURL() (string, error) {
+	return GetEnvOrSecret("HELM_REPOSITORY_URL", "helm-repository-url")
+}
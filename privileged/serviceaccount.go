@@ -0,0 +1,203 @@
+package cicd
+
+import (
+	"context"
+	"fmt"
+
+	"dagger.io/dagger"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/jpbarto/cicd-local/pkg/k8sclient"
+)
+
+// serviceAccountTokenAudience is the default audience requested on the
+// TokenRequest API when the caller doesn't supply one.
+const serviceAccountTokenAudience = "cicd-local"
+
+// serviceAccountTokenExpirySeconds is the default token lifetime requested
+// on the TokenRequest API when the caller doesn't supply one.
+const serviceAccountTokenExpirySeconds = int64(3600)
+
+// KubeconfigForServiceAccount resolves the named ServiceAccount using the
+// currently-injected (full-privilege) kubeconfig, mints a short-lived token
+// scoped to it, and synthesizes a minimal single-cluster/single-context
+// kubeconfig around that token. The result is returned as a *dagger.Secret
+// so downstream Kubectl*/Helm* calls can run with least-privilege
+// credentials scoped to one namespace instead of the operator's full-cluster
+// admin config.
+//
+// Token minting prefers the TokenRequest API (CoreV1().ServiceAccounts(ns).
+// CreateToken), falling back to the legacy SecretTypeServiceAccountToken
+// lookup for clusters too old to support TokenRequest.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - namespace: Namespace containing the ServiceAccount
+//   - saName: Name of the ServiceAccount to impersonate
+//
+// Returns a Dagger secret containing the synthesized kubeconfig content.
+//
+// Example usage:
+//
+//	scoped, err := cicd.KubeconfigForServiceAccount(ctx, client, "default", "deployer")
+//	if err != nil {
+//	    return "", fmt.Errorf("failed to scope credentials: %w", err)
+//	}
+//	output, err := cicd.HelmUpgrade(ctx, client, "myapp", chartRef, "default", nil, nil, scoped)
+func KubeconfigForServiceAccount(
+	ctx context.Context,
+	client *dagger.Client,
+	namespace string,
+	saName string,
+) (*dagger.Secret, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if saName == "" {
+		return nil, fmt.Errorf("service account name is required")
+	}
+
+	cfg, err := newK8sConfig(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	adminKubeconfig, err := cfg.kubeconfig.Plaintext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read injected kubeconfig: %w", err)
+	}
+
+	kc, err := k8sclient.New([]byte(adminKubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubernetes client: %w", err)
+	}
+
+	token, err := serviceAccountToken(ctx, kc, namespace, saName)
+	if err != nil {
+		return nil, err
+	}
+
+	caData, clusterServer, err := clusterConnectionInfo([]byte(adminKubeconfig))
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfigYAML, err := synthesizeKubeconfig(clusterServer, caData, namespace, saName, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize kubeconfig: %w", err)
+	}
+
+	return client.SetSecret(fmt.Sprintf("kubeconfig-%s-%s", namespace, saName), kubeconfigYAML), nil
+}
+
+// serviceAccountToken mints a token for the named ServiceAccount, preferring
+// the TokenRequest API and falling back to the legacy long-lived
+// SecretTypeServiceAccountToken secret for older clusters.
+func serviceAccountToken(ctx context.Context, kc *k8sclient.Client, namespace, saName string) (string, error) {
+	expiry := serviceAccountTokenExpirySeconds
+	tokenRequest, err := kc.Clientset.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, saName, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{serviceAccountTokenAudience},
+			ExpirationSeconds: &expiry,
+		},
+	}, metav1.CreateOptions{})
+	if err == nil {
+		return tokenRequest.Status.Token, nil
+	}
+	if !kubeerrors.IsNotFound(err) && !isNotImplemented(err) {
+		return "", fmt.Errorf("failed to create token for service account %q: %w", saName, err)
+	}
+
+	// Fall back to the legacy pattern: find the auto-generated secret of
+	// type kubernetes.io/service-account-token referencing this ServiceAccount.
+	sa, err := kc.Clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get service account %q: %w", saName, err)
+	}
+
+	for _, ref := range sa.Secrets {
+		secret, err := kc.Clientset.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if secret.Type != corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		if token, ok := secret.Data["token"]; ok {
+			return string(token), nil
+		}
+	}
+
+	return "", fmt.Errorf("no usable token found for service account %q in namespace %q (TokenRequest unsupported and no legacy token secret present)", saName, namespace)
+}
+
+// isNotImplemented reports whether err indicates the cluster's API server
+// doesn't support the TokenRequest API at all (as opposed to simply
+// rejecting this particular request).
+func isNotImplemented(err error) bool {
+	return kubeerrors.IsMethodNotSupported(err)
+}
+
+// clusterConnectionInfo extracts the CA bundle and server URL for the
+// current context out of the admin kubeconfig, so the synthesized
+// kubeconfig points at the same cluster.
+func clusterConnectionInfo(kubeconfig []byte) (caData []byte, server string, err error) {
+	apiCfg, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse injected kubeconfig: %w", err)
+	}
+
+	currentContext, ok := apiCfg.Contexts[apiCfg.CurrentContext]
+	if !ok {
+		return nil, "", fmt.Errorf("injected kubeconfig has no current context")
+	}
+	cluster, ok := apiCfg.Clusters[currentContext.Cluster]
+	if !ok {
+		return nil, "", fmt.Errorf("injected kubeconfig is missing cluster %q", currentContext.Cluster)
+	}
+
+	return cluster.CertificateAuthorityData, cluster.Server, nil
+}
+
+// synthesizeKubeconfig builds a minimal single-cluster/single-context/
+// single-authinfo kubeconfig scoped to namespace, serialized the same way
+// clientcmd.Write would produce it.
+func synthesizeKubeconfig(server string, caData []byte, namespace, saName, token string) (string, error) {
+	const contextName = "cicd-local-scoped"
+
+	apiCfg := clientcmdapi.Config{
+		Kind:       "Config",
+		APIVersion: "v1",
+		Clusters: map[string]*clientcmdapi.Cluster{
+			contextName: {
+				Server:                   server,
+				CertificateAuthorityData: caData,
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			contextName: {
+				Token: token,
+			},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			contextName: {
+				Cluster:   contextName,
+				AuthInfo:  contextName,
+				Namespace: namespace,
+			},
+		},
+		CurrentContext: contextName,
+	}
+
+	data, err := clientcmd.Write(apiCfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize kubeconfig: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,358 @@
+package cicd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// cosignImage runs cosign without requiring the caller to have the binary
+// installed locally; chainguard's distroless image keeps the attack surface
+// (and pull size) small.
+const cosignImage = "cgr.dev/chainguard/cosign:latest"
+
+// cosignKeySecretName is the secret file read from ~/.cicd-local/secrets/
+// when keyless (OIDC) signing is not in use.
+const cosignKeySecretName = "cosign.key"
+
+// slsaPredicateType is the in-toto predicate type used for the minimal
+// provenance attestation ContainerPush attaches when its sign option is set.
+const slsaPredicateType = "https://slsa.dev/provenance/v1"
+
+// SBOMPredicateType is the in-toto predicate type used for the SPDX SBOM
+// attestation produced by GenerateSBOM + AttestArtifact.
+const SBOMPredicateType = "https://spdx.dev/Document"
+
+// syftImage runs syft to generate a Software Bill of Materials without
+// requiring a local syft install.
+const syftImage = "anchore/syft:latest"
+
+// slsaProvenancePredicate assembles a minimal SLSA v1.0 provenance predicate
+// from the git commit (GIT_SHA/GITHUB_SHA env var) and the image reference
+// being attested. Callers needing a richer predicate (build parameters,
+// full materials list) should call ContainerAttest directly instead.
+func slsaProvenancePredicate(imageRef, digest string) string {
+	gitSHA := os.Getenv("GIT_SHA")
+	if gitSHA == "" {
+		gitSHA = os.Getenv("GITHUB_SHA")
+	}
+
+	predicate := map[string]interface{}{
+		"buildDefinition": map[string]interface{}{
+			"buildType": "https://cicd-local.dev/builder/v1",
+			"resolvedDependencies": []map[string]interface{}{
+				{"uri": "git+" + os.Getenv("GIT_REPOSITORY_URL"), "digest": map[string]string{"sha1": gitSHA}},
+			},
+		},
+		"runDetails": map[string]interface{}{
+			"builder": map[string]string{"id": "cicd-local"},
+			"materials": []map[string]interface{}{
+				{"uri": imageRef, "digest": map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(predicate)
+	if err != nil {
+		// Predicate construction from static, well-formed data cannot
+		// realistically fail; fall back to an empty object rather than
+		// propagating an error from what is otherwise a void helper.
+		return "{}"
+	}
+	return string(data)
+}
+
+// SigningConfigured reports whether cosign signing has been set up, via
+// either COSIGN_EXPERIMENTAL=1 (keyless) or a cosign.key secret file.
+// Callers for whom signing is optional should check this before calling
+// SignArtifact, rather than letting it fail when neither is configured.
+func SigningConfigured() bool {
+	if os.Getenv("COSIGN_EXPERIMENTAL") == "1" {
+		return true
+	}
+	_, err := LoadSecretFile(cosignKeySecretName)
+	return err == nil
+}
+
+// cosignContainer returns a cosign container authenticated either via
+// ambient OIDC (when COSIGN_EXPERIMENTAL=1 is set in the environment, the
+// convention cosign itself uses for keyless signing) or via a private key
+// loaded from the injected secrets.
+func cosignContainer(client *dagger.Client) (*dagger.Container, []string, error) {
+	container := client.Container().From(cosignImage)
+
+	if os.Getenv("COSIGN_EXPERIMENTAL") == "1" {
+		return container.WithEnvVariable("COSIGN_EXPERIMENTAL", "1"), nil, nil
+	}
+
+	keySecret, err := LoadSecretAsDaggerSecret(client, cosignKeySecretName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cosign key not found and COSIGN_EXPERIMENTAL=1 not set: %w", err)
+	}
+	container = container.WithMountedSecret("/run/secrets/cosign.key", keySecret)
+
+	return container, []string{"--key", "/run/secrets/cosign.key"}, nil
+}
+
+// ContainerSign keyless-signs (or key-signs, see cosignContainer) the digest
+// returned by ContainerPush using cosign.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - imageRef: Image reference as returned by ContainerPush (without digest)
+//   - digest: Manifest digest as returned by ContainerPush (e.g. "sha256:...")
+//
+// Returns the cosign CLI output, or an error.
+//
+// Example usage:
+//
+//	imageRef, digest, err := cicd.ContainerPush(ctx, client, images, "myapp", "1.2.3", nil, false)
+//	if err != nil {
+//	    return "", fmt.Errorf("push failed: %w", err)
+//	}
+//	output, err := cicd.ContainerSign(ctx, client, imageRef, digest)
+func ContainerSign(
+	ctx context.Context,
+	client *dagger.Client,
+	imageRef string,
+	digest string,
+) (string, error) {
+	if imageRef == "" {
+		return "", fmt.Errorf("image reference is required")
+	}
+	if digest == "" {
+		return "", fmt.Errorf("digest is required")
+	}
+
+	container, keyArgs, err := cosignContainer(client)
+	if err != nil {
+		return "", err
+	}
+
+	args := append([]string{"cosign", "sign", "--yes"}, keyArgs...)
+	args = append(args, digestRef(imageRef, digest))
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cosign sign failed for %s: %w", imageRef, err)
+	}
+
+	return output, nil
+}
+
+// ContainerAttest pushes an in-toto SLSA provenance attestation (or any
+// other predicate type) as an OCI referrer on the same repo as imageRef,
+// using cosign attest.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - imageRef: Image reference as returned by ContainerPush (without digest)
+//   - digest: Manifest digest as returned by ContainerPush (e.g. "sha256:...")
+//   - predicateType: The in-toto predicate type (e.g. "https://slsa.dev/provenance/v1")
+//   - predicateJSON: The predicate document contents, typically assembled
+//     from git commit, builder ID, and materials for a SLSA provenance statement
+//
+// Returns the cosign CLI output, or an error.
+//
+// Example usage:
+//
+//	output, err := cicd.ContainerAttest(ctx, client, imageRef, digest, "https://slsa.dev/provenance/v1", predicateJSON)
+//	if err != nil {
+//	    return "", fmt.Errorf("attestation failed: %w", err)
+//	}
+func ContainerAttest(
+	ctx context.Context,
+	client *dagger.Client,
+	imageRef string,
+	digest string,
+	predicateType string,
+	predicateJSON string,
+) (string, error) {
+	if imageRef == "" {
+		return "", fmt.Errorf("image reference is required")
+	}
+	if digest == "" {
+		return "", fmt.Errorf("digest is required")
+	}
+	if predicateType == "" {
+		return "", fmt.Errorf("predicate type is required")
+	}
+	if predicateJSON == "" {
+		return "", fmt.Errorf("predicate JSON is required")
+	}
+
+	container, keyArgs, err := cosignContainer(client)
+	if err != nil {
+		return "", err
+	}
+	container = container.WithNewFile("/tmp/predicate.json", predicateJSON)
+
+	args := append([]string{"cosign", "attest", "--yes",
+		"--type", predicateType,
+		"--predicate", "/tmp/predicate.json"},
+		keyArgs...)
+	args = append(args, digestRef(imageRef, digest))
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cosign attest failed for %s: %w", imageRef, err)
+	}
+
+	return output, nil
+}
+
+// VerifySignature verifies that ref (an image or OCI chart reference, tagged
+// or with an explicit digest) carries a valid cosign signature, so callers
+// like Deploy can refuse to act on an unsigned or tampered artifact.
+// publicKey verification is used when publicKey is non-empty; otherwise
+// verification falls back to keyless (ambient OIDC) mode, matching
+// cosignContainer's signing-side convention.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - ref: Image or chart reference to verify
+//   - publicKey: PEM-encoded cosign public key, or empty for keyless verification
+//
+// Returns an error if ref has no valid signature.
+//
+// Example usage:
+//
+//	if err := cicd.VerifySignature(ctx, client, imageRef, ""); err != nil {
+//	    return "", fmt.Errorf("refusing to deploy unsigned image: %w", err)
+//	}
+func VerifySignature(ctx context.Context, client *dagger.Client, ref string, publicKey string) error {
+	if ref == "" {
+		return fmt.Errorf("image or chart reference is required")
+	}
+
+	container := client.Container().From(cosignImage)
+	args := []string{"cosign", "verify"}
+
+	if publicKey != "" {
+		container = container.WithNewFile("/tmp/cosign.pub", publicKey)
+		args = append(args, "--key", "/tmp/cosign.pub")
+	} else {
+		container = container.WithEnvVariable("COSIGN_EXPERIMENTAL", "1")
+	}
+	args = append(args, ref)
+
+	if _, err := container.WithExec(args).Stdout(ctx); err != nil {
+		return fmt.Errorf("signature verification failed for %s: %w", ref, err)
+	}
+	return nil
+}
+
+// SignArtifact cosign-signs an arbitrary OCI artifact reference (an image or
+// a Helm OCI chart), sharing cosignContainer's key/keyless selection. It's
+// the same operation ContainerSign performs for ref@digest image
+// references, generalized for callers (like HelmPush) that only have a tag
+// reference, not a digest, to sign.
+func SignArtifact(ctx context.Context, client *dagger.Client, ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("reference is required")
+	}
+
+	container, keyArgs, err := cosignContainer(client)
+	if err != nil {
+		return "", err
+	}
+
+	args := append([]string{"cosign", "sign", "--yes"}, keyArgs...)
+	args = append(args, ref)
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cosign sign failed for %s: %w", ref, err)
+	}
+	return output, nil
+}
+
+// GenerateSBOM produces an SPDX-JSON Software Bill of Materials for ref
+// using syft, suitable for attaching to the artifact as a cosign
+// attestation via AttestArtifact/ContainerAttest with SBOMPredicateType.
+func GenerateSBOM(ctx context.Context, client *dagger.Client, ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("reference is required")
+	}
+
+	output, err := client.Container().
+		From(syftImage).
+		WithExec([]string{"syft", ref, "-o", "spdx-json"}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("sbom generation failed for %s: %w", ref, err)
+	}
+	return output, nil
+}
+
+// AttestArtifact pushes an in-toto attestation (e.g. an SBOM or SLSA
+// provenance document) for an arbitrary OCI artifact reference, sharing
+// cosignContainer's key/keyless selection. It generalizes ContainerAttest
+// the same way SignArtifact generalizes ContainerSign, for callers that
+// only have a tag reference rather than a pinned digest.
+func AttestArtifact(ctx context.Context, client *dagger.Client, ref string, predicateType string, predicateJSON string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("reference is required")
+	}
+	if predicateType == "" {
+		return "", fmt.Errorf("predicate type is required")
+	}
+	if predicateJSON == "" {
+		return "", fmt.Errorf("predicate JSON is required")
+	}
+
+	container, keyArgs, err := cosignContainer(client)
+	if err != nil {
+		return "", err
+	}
+	container = container.WithNewFile("/tmp/predicate.json", predicateJSON)
+
+	args := append([]string{"cosign", "attest", "--yes",
+		"--type", predicateType,
+		"--predicate", "/tmp/predicate.json"},
+		keyArgs...)
+	args = append(args, ref)
+
+	output, err := container.WithExec(args).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cosign attest failed for %s: %w", ref, err)
+	}
+	return output, nil
+}
+
+// digestRef builds a ref@digest string from a (possibly tagged) image
+// reference and a manifest digest, which is the form cosign expects so it
+// signs the exact content that was pushed rather than whatever a mutable tag
+// happens to point to later.
+func digestRef(imageRef, digest string) string {
+	repo := imageRef
+	if idx := lastColon(imageRef); idx != -1 {
+		repo = imageRef[:idx]
+	}
+	return fmt.Sprintf("%s@%s", repo, digest)
+}
+
+// lastColon returns the index of the last ':' after the final '/', so it
+// correctly strips a ":tag" suffix without mistaking a registry port
+// (e.g. "localhost:5000/myapp") for a tag separator.
+func lastColon(ref string) int {
+	lastSlash := -1
+	for i, c := range ref {
+		if c == '/' {
+			lastSlash = i
+		}
+	}
+	for i := len(ref) - 1; i > lastSlash; i-- {
+		if ref[i] == ':' {
+			return i
+		}
+	}
+	return -1
+}
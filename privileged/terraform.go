@@ -2,6 +2,8 @@ package cicd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 
@@ -11,28 +13,142 @@ import (
 // baseImage is the minimal Debian container used as a base for OpenTofu operations.
 const baseImage = "debian:bookworm-slim"
 
-// openTofuContainer returns a Dagger container with the OpenTofu binary installed.
-// It starts from a minimal Debian image, installs required dependencies, and then
-// uses the official OpenTofu install script to install the tofu CLI.
-func openTofuContainer(client *dagger.Client) *dagger.Container {
-	return client.Container().
+// defaultOpenTofuVersion pins the OpenTofu release installed when neither
+// WithTofuVersion nor the OPENTOFU_VERSION environment variable specify one.
+// Pinning (rather than always installing "latest") is what makes the
+// install step - and everything layered on top of it - reproducibly
+// cacheable across runs.
+const defaultOpenTofuVersion = "1.8.0"
+
+// pluginCacheDir is where OpenTofu's provider plugin cache
+// (TF_PLUGIN_CACHE_DIR) is mounted, shared across every tofu invocation
+// regardless of which terraformDir is in use.
+const pluginCacheDir = "/root/.terraform.d/plugin-cache"
+
+// tofuOptions configures openTofuContainer. See WithTofuVersion and
+// WithPluginCache.
+type tofuOptions struct {
+	version     string
+	pluginCache bool
+}
+
+// TofuOption configures openTofuContainer's pinned version and caching
+// behavior. See WithTofuVersion and WithPluginCache.
+type TofuOption func(*tofuOptions)
+
+// WithTofuVersion pins the OpenTofu release openTofuContainer installs,
+// overriding the OPENTOFU_VERSION environment variable and
+// defaultOpenTofuVersion.
+func WithTofuVersion(version string) TofuOption {
+	return func(o *tofuOptions) { o.version = version }
+}
+
+// WithPluginCache enables or disables the shared provider plugin cache
+// volume mounted at pluginCacheDir. It is enabled by default; pass false to
+// force a clean provider download (e.g. when debugging a provider cache
+// corruption issue).
+func WithPluginCache(enabled bool) TofuOption {
+	return func(o *tofuOptions) { o.pluginCache = enabled }
+}
+
+// resolveOpenTofuVersion returns the pinned OpenTofu version to install:
+// explicit > OPENTOFU_VERSION env var > defaultOpenTofuVersion.
+func resolveOpenTofuVersion(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if v := os.Getenv("OPENTOFU_VERSION"); v != "" {
+		return v
+	}
+	return defaultOpenTofuVersion
+}
+
+// openTofuContainer returns a Dagger container with the OpenTofu binary
+// installed. It starts from a minimal Debian image, installs required
+// dependencies, and installs the pinned tofu CLI version via the official
+// OpenTofu install script. Pinning the version (instead of always
+// installing "latest") lets Dagger cache this entire container build
+// across runs instead of re-running apt-get and the install script every
+// time. Unless disabled via WithPluginCache(false), a persistent cache
+// volume is also mounted for OpenTofu's provider plugin cache, so
+// `tofu init` doesn't re-download the same providers on every invocation.
+func openTofuContainer(client *dagger.Client, opts ...TofuOption) *dagger.Container {
+	cfg := tofuOptions{pluginCache: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	version := resolveOpenTofuVersion(cfg.version)
+
+	container := client.Container().
 		From(baseImage).
 		WithExec([]string{"apt-get", "update"}).
 		WithExec([]string{"apt-get", "install", "-y", "--no-install-recommends",
 			"curl", "gnupg", "software-properties-common", "git", "unzip", "ca-certificates"}).
 		WithExec([]string{"sh", "-c",
-			"curl --proto '=https' --tlsv1.2 -fsSL https://get.opentofu.org/install-opentofu.sh -o install-opentofu.sh && " +
-				"chmod +x install-opentofu.sh && " +
-				"./install-opentofu.sh --install-method deb && " +
-				"rm -f install-opentofu.sh"}).
+			fmt.Sprintf("curl --proto '=https' --tlsv1.2 -fsSL https://get.opentofu.org/install-opentofu.sh -o install-opentofu.sh && "+
+				"chmod +x install-opentofu.sh && "+
+				"./install-opentofu.sh --install-method deb --install-version %s && "+
+				"rm -f install-opentofu.sh", version)}).
 		WithExec([]string{"apt-get", "clean"}).
 		WithExec([]string{"rm", "-rf", "/var/lib/apt/lists/*"}).
 		WithExec([]string{"tofu", "--version"})
+
+	if cfg.pluginCache {
+		container = container.
+			WithMountedCache(pluginCacheDir, client.CacheVolume("opentofu-plugin-cache-"+version)).
+			WithEnvVariable("TF_PLUGIN_CACHE_DIR", pluginCacheDir)
+	}
+
+	return container
+}
+
+// terraformDirCacheKey derives a cache volume key for terraformDir's
+// `.terraform/` provider/module install directory from the contents of its
+// .terraform.lock.hcl, so two directories with identical locked provider
+// versions share a cache entry, and a changed lock file gets a fresh one
+// instead of reusing stale provider binaries. The second return value is
+// false when terraformDir has no lock file yet (e.g. before the first
+// `tofu init`, or a project that doesn't vendor one) - there's no
+// project-specific value to key on yet, so the caller should skip the
+// cache entirely rather than fall back to one shared key that unrelated
+// projects could stomp on.
+func terraformDirCacheKey(ctx context.Context, terraformDir *dagger.Directory) (string, bool) {
+	if terraformDir == nil {
+		return "", false
+	}
+	contents, err := terraformDir.File(".terraform.lock.hcl").Contents(ctx)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// withTerraformDirCache mounts a persistent cache volume at
+// /terraform/.terraform, keyed by terraformDirCacheKey, so provider
+// binaries and module installs survive across runs for an unchanged lock
+// file instead of being reinstalled by every `tofu init`. It's a no-op
+// when terraformDir has no lock file yet, since there's nothing
+// project-specific to key a shared volume on at that point.
+func withTerraformDirCache(ctx context.Context, client *dagger.Client, container *dagger.Container, terraformDir *dagger.Directory) *dagger.Container {
+	key, ok := terraformDirCacheKey(ctx, terraformDir)
+	if !ok {
+		return container
+	}
+	return container.WithMountedCache("/terraform/.terraform", client.CacheVolume("opentofu-terraform-dir-"+key))
+}
+
+// PlanResult bundles the two representations of a tofu plan: the binary
+// plan file tofu apply consumes, and its `tofu show -json` rendering that
+// tooling like TerraformPolicyCheck can evaluate.
+type PlanResult struct {
+	Binary *dagger.File
+	JSON   *dagger.File
 }
 
-// TerraformPlan runs tofu plan and returns the generated plan file.
-// This function executes tofu init and plan in the provided directory,
-// producing a binary plan file that can be inspected or applied later.
+// TerraformPlan runs tofu plan and returns the generated plan, both as the
+// binary plan file tofu apply consumes and as its JSON rendering (via
+// `tofu show -json`) for tooling such as TerraformPolicyCheck.
 //
 // Parameters:
 //   - ctx: Context for the operation
@@ -47,11 +163,11 @@ func openTofuContainer(client *dagger.Client) *dagger.Container {
 //   - AWS_SESSION_TOKEN: AWS session token (optional)
 //   - AWS_REGION: AWS region (optional)
 //
-// Returns the plan as a *dagger.File (binary plan file).
+// Returns the plan as a *PlanResult.
 //
 // Example usage:
 //
-//	planFile, err := privileged.TerraformPlan(ctx, client, terraformDir, varFileDir)
+//	plan, err := privileged.TerraformPlan(ctx, client, terraformDir, varFileDir)
 //	if err != nil {
 //	    return nil, fmt.Errorf("terraform plan failed: %w", err)
 //	}
@@ -60,15 +176,18 @@ func TerraformPlan(
 	client *dagger.Client,
 	terraformDir *dagger.Directory,
 	varFile *dagger.Directory,
-) (*dagger.File, error) {
+	backend *BackendConfig,
+	opts ...TofuOption,
+) (*PlanResult, error) {
 	if terraformDir == nil {
 		return nil, fmt.Errorf("terraform directory is required")
 	}
 
 	// Start with Debian container with OpenTofu installed
-	container := openTofuContainer(client).
+	container := openTofuContainer(client, opts...).
 		WithMountedDirectory("/terraform", terraformDir).
 		WithWorkdir("/terraform")
+	container = withTerraformDirCache(ctx, client, container, terraformDir)
 
 	// Add var file if provided
 	if varFile != nil {
@@ -78,8 +197,8 @@ func TerraformPlan(
 	// Pass through environment variables for Terraform/OpenTofu
 	container = passThroughTerraformEnv(container)
 
-	// Initialize OpenTofu
-	container = container.WithExec([]string{"tofu", "init"})
+	// Initialize OpenTofu, reconfiguring the backend if one was supplied
+	container = container.WithExec(initArgs(backend))
 
 	// Build tofu plan command â€” write plan to a file
 	planArgs := []string{"tofu", "plan", "-no-color", "-out=/terraform/tfplan"}
@@ -89,13 +208,86 @@ func TerraformPlan(
 		planArgs = append(planArgs, "-var-file=/vars/terraform.tfvars")
 	}
 
-	// Execute tofu plan
-	container = container.WithExec(planArgs)
+	// Execute tofu plan, then render it as JSON for policy tooling
+	container = container.
+		WithExec(planArgs).
+		WithExec([]string{"sh", "-c", "tofu show -json /terraform/tfplan > /terraform/tfplan.json"})
+
+	return &PlanResult{
+		Binary: container.File("/terraform/tfplan"),
+		JSON:   container.File("/terraform/tfplan.json"),
+	}, nil
+}
+
+// TerraformPlanJSON is a convenience wrapper around TerraformPlan for
+// callers that only need the JSON rendering of the plan (e.g. to feed
+// straight into TerraformPolicyCheck).
+func TerraformPlanJSON(
+	ctx context.Context,
+	client *dagger.Client,
+	terraformDir *dagger.Directory,
+	varFile *dagger.Directory,
+	backend *BackendConfig,
+	opts ...TofuOption,
+) (*dagger.File, error) {
+	plan, err := TerraformPlan(ctx, client, terraformDir, varFile, backend, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return plan.JSON, nil
+}
+
+// TerraformPolicyCheck evaluates a tofu plan's JSON rendering against Rego
+// policies using openpolicyagent/conftest, failing the build on policy
+// violations. This plugs a real "plan -> policy gate -> apply" stage into
+// the pipeline in place of the previous plan-then-apply-blind flow.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - planJSON: The JSON-rendered plan, as returned by TerraformPlan/TerraformPlanJSON
+//   - policyDir: Directory containing Rego policy files to evaluate the plan against
+//
+// Returns a JUnit-style XML report file. The returned error is non-nil both
+// when conftest itself fails to run and when it reports policy violations,
+// so callers can treat either case as a failed gate.
+//
+// Example usage:
+//
+//	report, err := privileged.TerraformPolicyCheck(ctx, client, plan.JSON, policyDir)
+//	if err != nil {
+//	    return nil, fmt.Errorf("terraform plan violates policy: %w", err)
+//	}
+func TerraformPolicyCheck(
+	ctx context.Context,
+	client *dagger.Client,
+	planJSON *dagger.File,
+	policyDir *dagger.Directory,
+) (*dagger.File, error) {
+	if planJSON == nil {
+		return nil, fmt.Errorf("plan JSON file is required")
+	}
+	if policyDir == nil {
+		return nil, fmt.Errorf("policy directory is required")
+	}
+
+	container := client.Container().
+		From("openpolicyagent/conftest:latest").
+		WithMountedFile("/work/tfplan.json", planJSON).
+		WithMountedDirectory("/work/policy", policyDir).
+		WithWorkdir("/work").
+		WithExec([]string{
+			"conftest", "test", "tfplan.json",
+			"--policy", "policy",
+			"--output", "junit",
+			"--output-file", "conftest-report.xml",
+		})
 
-	// Return the binary plan file
-	planFile := container.File("/terraform/tfplan")
+	if _, err := container.Sync(ctx); err != nil {
+		return nil, fmt.Errorf("conftest policy check failed: %w", err)
+	}
 
-	return planFile, nil
+	return container.File("/work/conftest-report.xml"), nil
 }
 
 // TerraformApply runs tofu apply and returns the resulting state file.
@@ -107,19 +299,21 @@ func TerraformPlan(
 //   - client: Dagger client instance
 //   - terraformDir: Directory containing Terraform/OpenTofu configuration files
 //   - varFile: Optional directory containing terraform.tfvars file (can be nil)
+//   - backend: Optional remote state backend configuration (can be nil for local state)
 //
 // Environment variables:
-//   - TF_VAR_*: Terraform variables (e.g., TF_VAR_region=us-east-1)
-//   - AWS_ACCESS_KEY_ID: AWS access key (if using AWS provider)
-//   - AWS_SECRET_ACCESS_KEY: AWS secret key (if using AWS provider)
-//   - AWS_SESSION_TOKEN: AWS session token (optional)
-//   - AWS_REGION: AWS region (optional)
+//   - TF_VAR_*, TF_*: Terraform/OpenTofu variables and settings
+//   - AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY / AWS_SESSION_TOKEN / AWS_REGION: AWS credentials
+//   - GOOGLE_APPLICATION_CREDENTIALS: GCP service account credentials path
+//   - ARM_*: Azure provider credentials
 //
-// Returns the state file as a *dagger.File (terraform.tfstate).
+// Returns the state file as a *dagger.File (terraform.tfstate). When backend
+// is set, this is the local copy tofu wrote after reading/writing through
+// the configured remote backend, not the backend's own source of truth.
 //
 // Example usage:
 //
-//	stateFile, err := privileged.TerraformApply(ctx, client, terraformDir, varFileDir)
+//	stateFile, err := privileged.TerraformApply(ctx, client, terraformDir, varFileDir, nil)
 //	if err != nil {
 //	    return nil, fmt.Errorf("terraform apply failed: %w", err)
 //	}
@@ -128,15 +322,18 @@ func TerraformApply(
 	client *dagger.Client,
 	terraformDir *dagger.Directory,
 	varFile *dagger.Directory,
+	backend *BackendConfig,
+	opts ...TofuOption,
 ) (*dagger.File, error) {
 	if terraformDir == nil {
 		return nil, fmt.Errorf("terraform directory is required")
 	}
 
 	// Start with Debian container with OpenTofu installed
-	container := openTofuContainer(client).
+	container := openTofuContainer(client, opts...).
 		WithMountedDirectory("/terraform", terraformDir).
 		WithWorkdir("/terraform")
+	container = withTerraformDirCache(ctx, client, container, terraformDir)
 
 	// Add var file if provided
 	if varFile != nil {
@@ -146,8 +343,8 @@ func TerraformApply(
 	// Pass through environment variables for Terraform/OpenTofu
 	container = passThroughTerraformEnv(container)
 
-	// Initialize OpenTofu
-	container = container.WithExec([]string{"tofu", "init"})
+	// Initialize OpenTofu, reconfiguring the backend if one was supplied
+	container = container.WithExec(initArgs(backend))
 
 	// Build tofu apply command
 	applyArgs := []string{"tofu", "apply", "-no-color", "-auto-approve"}
@@ -166,6 +363,99 @@ func TerraformApply(
 	return stateFile, nil
 }
 
+// TerraformDestroy mounts a prior state file and runs tofu destroy against
+// it, returning the (now-empty, or partially torn-down on error) state file.
+// Unlike TerraformPlan/TerraformApply, this requires an explicit stateFile
+// so the destroy operates on a real, previously-produced lifecycle rather
+// than an ephemeral container's local state.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - client: Dagger client instance
+//   - terraformDir: Directory containing Terraform/OpenTofu configuration files
+//   - varFile: Optional directory containing terraform.tfvars file (can be nil)
+//   - stateFile: Prior terraform.tfstate to destroy against (required when backend is nil)
+//
+// Returns the resulting state file as a *dagger.File (terraform.tfstate).
+//
+// Example usage:
+//
+//	stateFile, err := privileged.TerraformDestroy(ctx, client, terraformDir, nil, priorState)
+//	if err != nil {
+//	    return nil, fmt.Errorf("terraform destroy failed: %w", err)
+//	}
+func TerraformDestroy(
+	ctx context.Context,
+	client *dagger.Client,
+	terraformDir *dagger.Directory,
+	varFile *dagger.Directory,
+	stateFile *dagger.File,
+	backend *BackendConfig,
+	opts ...TofuOption,
+) (*dagger.File, error) {
+	if terraformDir == nil {
+		return nil, fmt.Errorf("terraform directory is required")
+	}
+	if stateFile == nil && backend == nil {
+		return nil, fmt.Errorf("a prior state file or backend configuration is required")
+	}
+
+	container := openTofuContainer(client, opts...).
+		WithMountedDirectory("/terraform", terraformDir).
+		WithWorkdir("/terraform")
+	container = withTerraformDirCache(ctx, client, container, terraformDir)
+
+	if stateFile != nil {
+		container = container.WithMountedFile("/terraform/terraform.tfstate", stateFile)
+	}
+	if varFile != nil {
+		container = container.WithMountedDirectory("/vars", varFile)
+	}
+
+	container = passThroughTerraformEnv(container)
+	container = container.WithExec(initArgs(backend))
+
+	destroyArgs := []string{"tofu", "destroy", "-no-color", "-auto-approve"}
+	if varFile != nil {
+		destroyArgs = append(destroyArgs, "-var-file=/vars/terraform.tfvars")
+	}
+	container = container.WithExec(destroyArgs)
+
+	return container.File("/terraform/terraform.tfstate"), nil
+}
+
+// BackendConfig configures a remote Terraform/OpenTofu state backend. Type
+// is the backend block name as declared in the Terraform source ("s3",
+// "gcs", "azurerm", "http"); Settings are passed through as individual
+// -backend-config=key=value flags to `tofu init`.
+type BackendConfig struct {
+	Type     string
+	Settings map[string]string
+}
+
+// WithBackend constructs a BackendConfig for the named backend type with the
+// given settings (e.g. WithBackend("s3", map[string]string{"bucket": "...",
+// "key": "...", "dynamodb_table": "...", "region": "..."})).
+func WithBackend(backendType string, settings map[string]string) *BackendConfig {
+	return &BackendConfig{Type: backendType, Settings: settings}
+}
+
+// initArgs builds the `tofu init` command line, adding a -backend-config
+// flag per backend setting and -reconfigure when a backend is supplied so
+// switching backend settings across runs doesn't get silently ignored.
+func initArgs(backend *BackendConfig) []string {
+	args := []string{"tofu", "init"}
+	if backend == nil {
+		return args
+	}
+
+	args = append(args, "-reconfigure")
+	for key, value := range backend.Settings {
+		args = append(args, fmt.Sprintf("-backend-config=%s=%s", key, value))
+	}
+	return args
+}
+
 // passThroughTerraformEnv passes through relevant environment variables to the OpenTofu container.
 func passThroughTerraformEnv(container *dagger.Container) *dagger.Container {
 	// AWS credentials
@@ -182,14 +472,21 @@ func passThroughTerraformEnv(container *dagger.Container) *dagger.Container {
 		container = container.WithEnvVariable("AWS_REGION", val)
 	}
 
-	// Pass through all TF_VAR_* environment variables
+	// GCP credentials
+	if val := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); val != "" {
+		container = container.WithEnvVariable("GOOGLE_APPLICATION_CREDENTIALS", val)
+	}
+
+	// Pass through all TF_* (including TF_VAR_*) and ARM_* (Azure) environment variables
 	for _, env := range os.Environ() {
-		if len(env) > 7 && env[:7] == "TF_VAR_" {
-			// Split on first '='
-			parts := splitOnce(env, "=")
-			if len(parts) == 2 {
-				container = container.WithEnvVariable(parts[0], parts[1])
-			}
+		isTerraform := len(env) > 3 && env[:3] == "TF_"
+		isAzure := len(env) > 4 && env[:4] == "ARM_"
+		if !isTerraform && !isAzure {
+			continue
+		}
+		parts := splitOnce(env, "=")
+		if len(parts) == 2 {
+			container = container.WithEnvVariable(parts[0], parts[1])
 		}
 	}
 